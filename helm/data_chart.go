@@ -0,0 +1,312 @@
+package helm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"sigs.k8s.io/yaml"
+)
+
+// readmeFileNames are the names of the README of a chart, as used by
+// `helm show readme`
+var readmeFileNames = []string{"readme.md", "readme.txt", "readme"}
+
+func dataChart() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataChartRead,
+		Schema: map[string]*schema.Schema{
+			"repository": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Repository where to locate the requested chart. If is a URL the chart is installed without installing the repository.",
+			},
+			"repository_key_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The repositories cert key file",
+			},
+			"repository_cert_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The repositories cert file",
+			},
+			"repository_ca_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The Repositories CA File",
+			},
+			"repository_username": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Username for HTTP basic authentication",
+			},
+			"repository_password": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				Description: "Password for HTTP basic authentication",
+			},
+			"chart": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Chart name to be inspected. A path, a URL or an `oci://` reference may be used.",
+			},
+			"version": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Specify the exact chart version to inspect. If this is not specified, the latest version is used.",
+			},
+			"devel": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Use chart development versions, too. Equivalent to version '>0.0.0-0'. If `version` is set, this is ignored",
+			},
+			"verify": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     defaultAttributes["verify"],
+				Description: "Verify the package before using it.",
+			},
+			"keyring": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     os.ExpandEnv("$HOME/.gnupg/pubring.gpg"),
+				Description: "Location of public keys used for verification. Used only if `verify` is true",
+			},
+			"name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The name of the chart.",
+			},
+			"app_version": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The version number of the application packaged by the chart.",
+			},
+			"kube_version": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The SemVer range of compatible Kubernetes versions.",
+			},
+			"type": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The type of the chart, application or library.",
+			},
+			"description": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "A one-sentence description of the chart.",
+			},
+			"dependencies": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The dependencies of the chart.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The name of the dependency.",
+						},
+						"version": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The version or SemVer range of the dependency.",
+						},
+						"repository": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The repository of the dependency.",
+						},
+						"condition": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The values path enabling the dependency.",
+						},
+						"alias": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The alias of the dependency.",
+						},
+					},
+				},
+			},
+			"maintainers": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The maintainers of the chart.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The name of the maintainer.",
+						},
+						"email": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The email of the maintainer.",
+						},
+						"url": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The URL of the maintainer.",
+						},
+					},
+				},
+			},
+			"values": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The default values of the chart in YAML.",
+			},
+			"values_json": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The default values of the chart, JSON encoded.",
+			},
+			"readme": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The README of the chart.",
+			},
+			"values_schema": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The JSON schema of the values of the chart, if any.",
+			},
+		},
+	}
+}
+
+func dataChartRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	logID := fmt.Sprintf("[dataChartRead: %s]", d.Get("chart").(string))
+	debug("%s Started", logID)
+
+	m := meta.(*Meta)
+
+	cpo, chartName, err := chartPathOptions(d, m)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	debug("%s Getting chart", logID)
+	c, _, err := getChart(d, m, chartName, cpo)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := setChartAttributes(d, c); err != nil {
+		return diag.FromErr(err)
+	}
+
+	debug("%s Done", logID)
+
+	return nil
+}
+
+func setChartAttributes(d *schema.ResourceData, c *chart.Chart) error {
+	d.SetId(fmt.Sprintf("%s-%s", c.Metadata.Name, c.Metadata.Version))
+
+	values, err := chartValuesYAML(c)
+	if err != nil {
+		return err
+	}
+
+	valuesJSON, err := json.Marshal(c.Values)
+	if err != nil {
+		return err
+	}
+
+	attributes := map[string]interface{}{
+		"name":          c.Metadata.Name,
+		"version":       c.Metadata.Version,
+		"app_version":   c.Metadata.AppVersion,
+		"kube_version":  c.Metadata.KubeVersion,
+		"type":          c.Metadata.Type,
+		"description":   c.Metadata.Description,
+		"dependencies":  flattenChartDependencies(c.Metadata.Dependencies),
+		"maintainers":   flattenChartMaintainers(c.Metadata.Maintainers),
+		"values":        values,
+		"values_json":   string(valuesJSON),
+		"readme":        chartReadme(c),
+		"values_schema": string(c.Schema),
+	}
+
+	for k, v := range attributes {
+		if err := d.Set(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chartValuesYAML returns the values file of the chart as is, keeping its
+// comments, falling back to the parsed values
+func chartValuesYAML(c *chart.Chart) (string, error) {
+	for _, f := range c.Raw {
+		if f.Name == chartutil.ValuesfileName {
+			return string(f.Data), nil
+		}
+	}
+
+	if len(c.Values) == 0 {
+		return "", nil
+	}
+
+	values, err := yaml.Marshal(c.Values)
+	if err != nil {
+		return "", err
+	}
+	return string(values), nil
+}
+
+func chartReadme(c *chart.Chart) string {
+	for _, n := range readmeFileNames {
+		for _, f := range c.Files {
+			if strings.EqualFold(f.Name, n) {
+				return string(f.Data)
+			}
+		}
+	}
+	return ""
+}
+
+func flattenChartDependencies(dependencies []*chart.Dependency) []map[string]interface{} {
+	result := []map[string]interface{}{}
+
+	for _, dep := range dependencies {
+		result = append(result, map[string]interface{}{
+			"name":       dep.Name,
+			"version":    dep.Version,
+			"repository": dep.Repository,
+			"condition":  dep.Condition,
+			"alias":      dep.Alias,
+		})
+	}
+
+	return result
+}
+
+func flattenChartMaintainers(maintainers []*chart.Maintainer) []map[string]interface{} {
+	result := []map[string]interface{}{}
+
+	for _, mt := range maintainers {
+		result = append(result, map[string]interface{}{
+			"name":  mt.Name,
+			"email": mt.Email,
+			"url":   mt.URL,
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,99 @@
+package helm
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"helm.sh/helm/v3/pkg/chart/loader"
+)
+
+func TestAccDataChart_basic(t *testing.T) {
+	datasourceAddress := fmt.Sprintf("data.helm_chart.%s", testResourceName)
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{{
+			Config: testAccDataHelmChartConfig(testResourceName, testRepositoryURL, "test-chart", "1.2.3"),
+			Check: resource.ComposeAggregateTestCheckFunc(
+				resource.TestCheckResourceAttr(datasourceAddress, "name", "test-chart"),
+				resource.TestCheckResourceAttr(datasourceAddress, "version", "1.2.3"),
+				resource.TestCheckResourceAttr(datasourceAddress, "app_version", "1.19.5"),
+				resource.TestCheckResourceAttr(datasourceAddress, "type", "application"),
+				resource.TestCheckResourceAttrSet(datasourceAddress, "values"),
+				resource.TestCheckResourceAttrSet(datasourceAddress, "values_json"),
+				resource.TestCheckResourceAttr(datasourceAddress, "values_schema", ""),
+			),
+		}, {
+			Config: testAccDataHelmChartConfig(testResourceName, testRepositoryURL, "schema-chart", ""),
+			Check: resource.ComposeAggregateTestCheckFunc(
+				resource.TestCheckResourceAttr(datasourceAddress, "name", "schema-chart"),
+				resource.TestCheckResourceAttr(datasourceAddress, "version", "0.1.0"),
+				resource.TestCheckResourceAttr(datasourceAddress, "maintainers.#", "1"),
+				resource.TestCheckResourceAttrSet(datasourceAddress, "readme"),
+				resource.TestCheckResourceAttrSet(datasourceAddress, "values_schema"),
+			),
+		}},
+	})
+}
+
+func TestSetChartAttributes(t *testing.T) {
+	c, err := loader.Load(filepath.Join(testChartsPath, "schema-chart"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := schema.TestResourceDataRaw(t, dataChart().Schema, map[string]interface{}{"chart": "schema-chart"})
+	if err := setChartAttributes(d, c); err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Id() != "schema-chart-0.1.0" {
+		t.Fatalf("unexpected id %q", d.Id())
+	}
+
+	expected := map[string]interface{}{
+		"name":                "schema-chart",
+		"version":             "0.1.0",
+		"app_version":         "1.0.0",
+		"kube_version":        ">=1.16.0-0",
+		"type":                "application",
+		"maintainers.#":       1,
+		"maintainers.0.email": "jane@example.com",
+		"dependencies.#":      0,
+		"values_json":         `{"image":{"repository":"nginx","tag":"1.19.5"},"replicaCount":1,"service":{"port":80}}`,
+	}
+
+	for k, v := range expected {
+		if d.Get(k) != v {
+			t.Errorf("expected %s to be %v, got %v", k, v, d.Get(k))
+		}
+	}
+
+	if values := d.Get("values").(string); !strings.Contains(values, "# Number of replicas") {
+		t.Errorf("expected the comments of the values file to be kept, got %q", values)
+	}
+
+	if readme := d.Get("readme").(string); !strings.HasPrefix(readme, "# schema-chart") {
+		t.Errorf("unexpected readme %q", readme)
+	}
+
+	if s := d.Get("values_schema").(string); !strings.Contains(s, `"replicaCount"`) {
+		t.Errorf("unexpected values schema %q", s)
+	}
+}
+
+func testAccDataHelmChartConfig(resource, repository, chart, version string) string {
+	return fmt.Sprintf(`
+		data "helm_chart" "%s" {
+			repository = %q
+			chart      = %q
+			version    = %q
+		}
+	`, resource, repository, chart, version)
+}
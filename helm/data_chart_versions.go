@@ -0,0 +1,240 @@
+package helm
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pkg/errors"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/repo"
+	helmtime "helm.sh/helm/v3/pkg/time"
+)
+
+func dataChartVersions() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataChartVersionsRead,
+		Schema: map[string]*schema.Schema{
+			"repository": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Repository where to locate the requested chart. Either a URL or the name of a configured repository.",
+			},
+			"repository_key_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The repositories cert key file",
+			},
+			"repository_cert_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The repositories cert file",
+			},
+			"repository_ca_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The Repositories CA File",
+			},
+			"repository_username": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Username for HTTP basic authentication",
+			},
+			"repository_password": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				Description: "Password for HTTP basic authentication",
+			},
+			"chart": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Chart name to list the versions of.",
+			},
+			"versions": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The versions of the chart published in the repository, newest first.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"version": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "A SemVer 2 conformant version string of the chart.",
+						},
+						"app_version": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The version number of the application packaged by the chart.",
+						},
+						"created": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The time the version was added to the repository.",
+						},
+						"digest": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The SHA256 digest of the chart package.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataChartVersionsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	logID := fmt.Sprintf("[dataChartVersionsRead: %s]", d.Get("chart").(string))
+	debug("%s Started", logID)
+
+	m := meta.(*Meta)
+
+	repository := d.Get("repository").(string)
+	repositoryURL, chartName, err := resolveChartName(repository, strings.TrimSpace(d.Get("chart").(string)))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	cpo := &action.ChartPathOptions{
+		CaFile:   d.Get("repository_ca_file").(string),
+		CertFile: d.Get("repository_cert_file").(string),
+		KeyFile:  d.Get("repository_key_file").(string),
+		RepoURL:  repositoryURL,
+		Username: d.Get("repository_username").(string),
+		Password: d.Get("repository_password").(string),
+	}
+
+	versions, err := getChartVersions(m, cpo, chartName)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if versions == nil {
+		return diag.Errorf("chart %q is not published in a chart repository, its versions cannot be listed", chartName)
+	}
+
+	d.SetId(fmt.Sprintf("%s/%s", repository, chartName))
+
+	if err := d.Set("versions", flattenChartVersions(versions)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	debug("%s Done", logID)
+
+	return nil
+}
+
+// getChartVersions returns the versions of the chart published in its
+// repository, newest first, from the index of the repository in the cache,
+// downloaded once per run.
+// Charts not located through a repository index, like local paths, URLs of
+// packages or OCI references, have no versions.
+func getChartVersions(m *Meta, cpo *action.ChartPathOptions, name string) (repo.ChartVersions, error) {
+	if isOCIChart(name) {
+		return nil, nil
+	}
+
+	e := &repo.Entry{
+		URL:      cpo.RepoURL,
+		Username: cpo.Username,
+		Password: cpo.Password,
+		CertFile: cpo.CertFile,
+		KeyFile:  cpo.KeyFile,
+		CAFile:   cpo.CaFile,
+	}
+
+	if e.URL == "" {
+		// A chart in a configured repository is referenced as repository/chart
+		parts := strings.SplitN(name, "/", 2)
+		if len(parts) != 2 {
+			return nil, nil
+		}
+
+		f, err := loadRepositoryFile(m)
+		if err != nil {
+			return nil, err
+		}
+
+		if e = f.Get(parts[0]); e == nil {
+			return nil, nil
+		}
+		name = parts[1]
+	}
+
+	if e.Name == "" {
+		// Repositories given by URL are cached under a name derived from it
+		entry := *e
+		entry.Name = fmt.Sprintf("url-%x", sha256.Sum256([]byte(e.URL)))
+		e = &entry
+	}
+
+	m.Lock()
+	index, err := loadRepositoryIndex(m, e)
+	m.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	versions, ok := index.Entries[name]
+	if !ok || len(versions) == 0 {
+		return nil, errors.Errorf("chart %q not found in %s repository", name, e.URL)
+	}
+
+	sort.Sort(sort.Reverse(versions))
+	return versions, nil
+}
+
+// resolveChartVersion returns the newest of the versions, sorted newest first,
+// satisfying the constraint. An empty constraint matches the newest stable
+// version, like in `helm install`.
+func resolveChartVersion(versions repo.ChartVersions, constraint string) (*repo.ChartVersion, error) {
+	constraint = strings.TrimSpace(constraint)
+	if constraint == "" {
+		constraint = "*"
+	}
+
+	for _, cv := range versions {
+		if cv.Version == constraint {
+			return cv, nil
+		}
+	}
+
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid chart version constraint %q", constraint)
+	}
+
+	for _, cv := range versions {
+		v, err := semver.NewVersion(cv.Version)
+		if err != nil {
+			continue
+		}
+
+		if c.Check(v) {
+			return cv, nil
+		}
+	}
+
+	return nil, errors.Errorf("no version of the chart matches the constraint %q", constraint)
+}
+
+func flattenChartVersions(versions repo.ChartVersions) []map[string]interface{} {
+	result := []map[string]interface{}{}
+
+	for _, cv := range versions {
+		result = append(result, map[string]interface{}{
+			"version":     cv.Version,
+			"app_version": cv.AppVersion,
+			"created":     formatTime(helmtime.Time{Time: cv.Created}),
+			"digest":      cv.Digest,
+		})
+	}
+
+	return result
+}
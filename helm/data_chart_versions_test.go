@@ -0,0 +1,162 @@
+package helm
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+const testIndex = `apiVersion: v1
+entries:
+  app:
+  - name: app
+    version: 1.4.0
+    appVersion: "1.0"
+    created: "2021-01-01T00:00:00Z"
+    digest: abc
+    urls: [app-1.4.0.tgz]
+  - name: app
+    version: 1.5.0-rc.1
+    created: "2021-03-01T00:00:00Z"
+    urls: [app-1.5.0-rc.1.tgz]
+  - name: app
+    version: 1.4.2
+    appVersion: "1.2"
+    created: "2021-02-01T00:00:00Z"
+    digest: def
+    urls: [app-1.4.2.tgz]
+  - name: app
+    version: 2.0.0
+    created: "2021-04-01T00:00:00Z"
+    urls: [app-2.0.0.tgz]
+`
+
+func TestAccDataChartVersions_basic(t *testing.T) {
+	datasourceAddress := fmt.Sprintf("data.helm_chart_versions.%s", testResourceName)
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{{
+			Config: fmt.Sprintf(`
+				data "helm_chart_versions" "%s" {
+					repository = %q
+					chart      = "test-chart"
+				}
+			`, testResourceName, testRepositoryURL),
+			Check: resource.ComposeAggregateTestCheckFunc(
+				resource.TestCheckResourceAttr(datasourceAddress, "versions.#", "2"),
+				resource.TestCheckResourceAttr(datasourceAddress, "versions.0.version", "2.0.0"),
+				resource.TestCheckResourceAttr(datasourceAddress, "versions.1.version", "1.2.3"),
+				resource.TestCheckResourceAttrSet(datasourceAddress, "versions.0.created"),
+				resource.TestCheckResourceAttrSet(datasourceAddress, "versions.0.digest"),
+			),
+		}},
+	})
+}
+
+func TestGetChartVersions(t *testing.T) {
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/index.yaml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		downloads++
+		fmt.Fprint(w, testIndex)
+	}))
+	defer server.Close()
+
+	m := newTestMeta(t)
+
+	versions, err := getChartVersions(m, &action.ChartPathOptions{RepoURL: server.URL}, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"2.0.0", "1.5.0-rc.1", "1.4.2", "1.4.0"}
+	if len(versions) != len(expected) {
+		t.Fatalf("expected %d versions, got %d", len(expected), len(versions))
+	}
+	for i, v := range expected {
+		if versions[i].Version != v {
+			t.Errorf("expected version %d to be %s, got %s", i, v, versions[i].Version)
+		}
+	}
+
+	if _, err := getChartVersions(m, &action.ChartPathOptions{RepoURL: server.URL}, "missing"); err == nil {
+		t.Error("expected an error for a chart missing from the repository")
+	}
+
+	if downloads != 1 {
+		t.Errorf("expected the index to be downloaded once, got %d downloads", downloads)
+	}
+
+	// Charts in configured repositories are looked up in the repositories file
+	f := repo.NewFile()
+	f.Update(&repo.Entry{Name: "stable", URL: server.URL})
+	if err := f.WriteFile(m.Settings.RepositoryConfig, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err = getChartVersions(m, &action.ChartPathOptions{}, "stable/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != len(expected) {
+		t.Fatalf("expected %d versions, got %d", len(expected), len(versions))
+	}
+
+	for _, name := range []string{"./testdata/charts/test-chart", "unknown/app", "oci://registry.local/charts/app"} {
+		versions, err := getChartVersions(m, &action.ChartPathOptions{}, name)
+		if err != nil || versions != nil {
+			t.Errorf("expected no versions for %q, got %v, %v", name, versions, err)
+		}
+	}
+}
+
+func TestResolveChartVersion(t *testing.T) {
+	versions := repo.ChartVersions{}
+	for _, v := range []string{"2.0.0", "1.5.0-rc.1", "1.4.2", "1.4.0"} {
+		versions = append(versions, &repo.ChartVersion{Metadata: &chart.Metadata{Version: v}})
+	}
+
+	tests := []struct {
+		constraint, expected string
+		fails                bool
+	}{
+		{"", "2.0.0", false},
+		{"~1.4", "1.4.2", false},
+		{"1.4.0", "1.4.0", false},
+		{">0.0.0-0", "2.0.0", false},
+		{"~1.5.0-0", "1.5.0-rc.1", false},
+		{"^3", "", true},
+		{"not a constraint", "", true},
+	}
+
+	for _, tt := range tests {
+		cv, err := resolveChartVersion(versions, tt.constraint)
+		if tt.fails {
+			if err == nil {
+				t.Errorf("expected an error for %q, got version %s", tt.constraint, cv.Version)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("unexpected error for %q: %s", tt.constraint, err)
+			continue
+		}
+
+		if cv.Version != tt.expected {
+			t.Errorf("expected %q to resolve to %s, got %s", tt.constraint, tt.expected, cv.Version)
+		}
+	}
+}
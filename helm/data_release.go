@@ -0,0 +1,157 @@
+package helm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+func dataRelease() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataReleaseRead,
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Release name.",
+			},
+			"namespace": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Namespace of the release.",
+				DefaultFunc: schema.EnvDefaultFunc("HELM_NAMESPACE", "default"),
+			},
+			"chart": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The name of the chart.",
+			},
+			"version": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "A SemVer 2 conformant version string of the chart.",
+			},
+			"app_version": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The version number of the application being deployed.",
+			},
+			"status": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Status of the release.",
+			},
+			"revision": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Version is an int32 which represents the version of the release.",
+			},
+			"description": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Description of the release.",
+			},
+			"values": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "Set of extra values, added to the chart. JSON encoded, marked sensitive as it may hold secrets.",
+			},
+			"computed_values": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The values of the chart merged with the extra values. JSON encoded, marked sensitive as it may hold secrets.",
+			},
+			"notes": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Rendered notes if the chart contains a `NOTES.txt`.",
+			},
+			"manifest": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The rendered manifest as JSON. The data of Secrets is redacted.",
+			},
+		},
+	}
+}
+
+func dataReleaseRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	logID := fmt.Sprintf("[dataReleaseRead: %s]", d.Get("name").(string))
+	debug("%s Started", logID)
+
+	m := meta.(*Meta)
+	n := d.Get("namespace").(string)
+
+	c, err := m.GetHelmConfiguration(n)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	name := d.Get("name").(string)
+	r, err := getRelease(m, c, name)
+	if err == errReleaseNotFound {
+		return diag.Errorf("release %q not found in namespace %q", name, n)
+	} else if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := setDataReleaseAttributes(d, r); err != nil {
+		return diag.FromErr(err)
+	}
+
+	debug("%s Done", logID)
+
+	return nil
+}
+
+func setDataReleaseAttributes(d *schema.ResourceData, r *release.Release) error {
+	d.SetId(fmt.Sprintf("%s/%s", r.Namespace, r.Name))
+
+	values, err := json.Marshal(r.Config)
+	if err != nil {
+		return err
+	}
+
+	coalesced, err := chartutil.CoalesceValues(r.Chart, r.Config)
+	if err != nil {
+		return err
+	}
+
+	computedValues, err := json.Marshal(coalesced)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := convertYAMLManifestToJSON(r.Manifest)
+	if err != nil {
+		return err
+	}
+
+	attributes := map[string]interface{}{
+		"namespace":       r.Namespace,
+		"chart":           r.Chart.Metadata.Name,
+		"version":         r.Chart.Metadata.Version,
+		"app_version":     r.Chart.Metadata.AppVersion,
+		"status":          r.Info.Status.String(),
+		"revision":        r.Version,
+		"description":     r.Info.Description,
+		"values":          string(values),
+		"computed_values": string(computedValues),
+		"notes":           r.Info.Notes,
+		"manifest":        manifest,
+	}
+
+	for k, v := range attributes {
+		if err := d.Set(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
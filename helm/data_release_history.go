@@ -0,0 +1,161 @@
+package helm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+func dataReleaseHistory() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataReleaseHistoryRead,
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Release name.",
+			},
+			"namespace": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Namespace of the release.",
+				DefaultFunc: schema.EnvDefaultFunc("HELM_NAMESPACE", "default"),
+			},
+			"max": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      256,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Maximum number of revisions to include in the history. Use 0 for no limit.",
+			},
+			"history": releaseHistorySchema(),
+		},
+	}
+}
+
+// releaseHistorySchema returns the schema of a list of release revisions,
+// oldest first
+func releaseHistorySchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Computed:    true,
+		Description: "The revisions of the release, oldest first.",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"revision": {
+					Type:        schema.TypeInt,
+					Computed:    true,
+					Description: "Version is an int32 which represents the version of the release.",
+				},
+				"status": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "Status of the revision.",
+				},
+				"chart": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The name of the chart.",
+				},
+				"version": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "A SemVer 2 conformant version string of the chart.",
+				},
+				"app_version": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The version number of the application being deployed.",
+				},
+				"description": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "Description of the revision.",
+				},
+				"updated": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The time the revision was deployed.",
+				},
+			},
+		},
+	}
+}
+
+func dataReleaseHistoryRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	logID := fmt.Sprintf("[dataReleaseHistoryRead: %s]", d.Get("name").(string))
+	debug("%s Started", logID)
+
+	m := meta.(*Meta)
+	n := d.Get("namespace").(string)
+
+	c, err := m.GetHelmConfiguration(n)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	name := d.Get("name").(string)
+	history, err := getReleaseHistory(m, c, name, d.Get("max").(int))
+	if err == errReleaseNotFound {
+		return diag.Errorf("release %q not found in namespace %q", name, n)
+	} else if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(fmt.Sprintf("%s/%s", n, name))
+
+	if err := d.Set("history", flattenReleaseHistory(history)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	debug("%s Done", logID)
+
+	return nil
+}
+
+// getReleaseHistory returns the last max revisions of the release, oldest
+// first. A max of 0 returns all of them.
+func getReleaseHistory(m *Meta, cfg *action.Configuration, name string, max int) ([]*release.Release, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	history, err := action.NewHistory(cfg).Run(name)
+	if err == driver.ErrReleaseNotFound || (err == nil && len(history) == 0) {
+		return nil, errReleaseNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	releaseutil.SortByRevision(history)
+
+	if max > 0 && len(history) > max {
+		history = history[len(history)-max:]
+	}
+
+	return history, nil
+}
+
+func flattenReleaseHistory(history []*release.Release) []map[string]interface{} {
+	result := []map[string]interface{}{}
+
+	for _, r := range history {
+		result = append(result, map[string]interface{}{
+			"revision":    r.Version,
+			"status":      r.Info.Status.String(),
+			"chart":       r.Chart.Metadata.Name,
+			"version":     r.Chart.Metadata.Version,
+			"app_version": r.Chart.Metadata.AppVersion,
+			"description": r.Info.Description,
+			"updated":     formatTime(r.Info.LastDeployed),
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,105 @@
+package helm
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+	helmtime "helm.sh/helm/v3/pkg/time"
+)
+
+func TestAccDataReleaseHistory_basic(t *testing.T) {
+	name := randName("data-history")
+	namespace := createRandomNamespace(t)
+	defer deleteNamespace(t, namespace)
+
+	datasourceAddress := fmt.Sprintf("data.helm_release_history.%s", testResourceName)
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckHelmReleaseDestroy(namespace),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataHelmReleaseHistoryConfigBasic(testResourceName, namespace, name, "1.2.3"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(datasourceAddress, "history.#", "1"),
+					resource.TestCheckResourceAttr(datasourceAddress, "history.0.revision", "1"),
+					resource.TestCheckResourceAttr(datasourceAddress, "history.0.status", release.StatusDeployed.String()),
+					resource.TestCheckResourceAttr(datasourceAddress, "history.0.version", "1.2.3"),
+				),
+			},
+			{
+				Config: testAccDataHelmReleaseHistoryConfigBasic(testResourceName, namespace, name, "2.0.0"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(datasourceAddress, "history.#", "2"),
+					resource.TestCheckResourceAttr(datasourceAddress, "history.0.status", release.StatusSuperseded.String()),
+					resource.TestCheckResourceAttr(datasourceAddress, "history.1.revision", "2"),
+					resource.TestCheckResourceAttr(datasourceAddress, "history.1.status", release.StatusDeployed.String()),
+					resource.TestCheckResourceAttr(datasourceAddress, "history.1.version", "2.0.0"),
+					resource.TestCheckResourceAttrSet(datasourceAddress, "history.1.updated"),
+				),
+			},
+		},
+	})
+}
+
+func TestFlattenReleaseHistory(t *testing.T) {
+	deployed := helmtime.Unix(1600000000, 0)
+
+	history := []*release.Release{{
+		Name:    "example",
+		Version: 3,
+		Info: &release.Info{
+			Status:       release.StatusDeployed,
+			Description:  "Upgrade complete",
+			LastDeployed: deployed,
+		},
+		Chart: &chart.Chart{
+			Metadata: &chart.Metadata{Name: "example-chart", Version: "1.0.0", AppVersion: "2.0.0"},
+		},
+	}}
+
+	result := flattenReleaseHistory(history)
+	if len(result) != 1 {
+		t.Fatalf("expected 1 revision, got %d", len(result))
+	}
+
+	expected := map[string]interface{}{
+		"revision":    3,
+		"status":      "deployed",
+		"chart":       "example-chart",
+		"version":     "1.0.0",
+		"app_version": "2.0.0",
+		"description": "Upgrade complete",
+		"updated":     "2020-09-13T12:26:40Z",
+	}
+
+	for k, v := range expected {
+		if result[0][k] != v {
+			t.Errorf("expected %s to be %v, got %v", k, v, result[0][k])
+		}
+	}
+}
+
+func testAccDataHelmReleaseHistoryConfigBasic(resource, ns, name, version string) string {
+	return fmt.Sprintf(`
+		resource "helm_release" "%s" {
+			name        = %q
+			namespace   = %q
+			repository  = %q
+			chart       = "test-chart"
+			version     = %q
+		}
+
+		data "helm_release_history" "%s" {
+			name      = helm_release.%s.name
+			namespace = helm_release.%s.namespace
+
+			depends_on = [helm_release.%s]
+		}
+	`, resource, name, ns, testRepositoryURL, version, resource, resource, resource, resource)
+}
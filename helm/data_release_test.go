@@ -0,0 +1,121 @@
+package helm
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+func TestAccDataRelease_basic(t *testing.T) {
+	name := randName("data-release")
+	namespace := createRandomNamespace(t)
+	defer deleteNamespace(t, namespace)
+
+	datasourceAddress := fmt.Sprintf("data.helm_release.%s", testResourceName)
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckHelmReleaseDestroy(namespace),
+		Steps: []resource.TestStep{{
+			Config: testAccDataHelmReleaseConfigBasic(testResourceName, namespace, name, "1.2.3"),
+			Check: resource.ComposeAggregateTestCheckFunc(
+				resource.TestCheckResourceAttr(datasourceAddress, "chart", "test-chart"),
+				resource.TestCheckResourceAttr(datasourceAddress, "version", "1.2.3"),
+				resource.TestCheckResourceAttr(datasourceAddress, "app_version", "1.19.5"),
+				resource.TestCheckResourceAttr(datasourceAddress, "status", release.StatusDeployed.String()),
+				resource.TestCheckResourceAttr(datasourceAddress, "revision", "1"),
+				resource.TestCheckResourceAttr(datasourceAddress, "values", `{"fizz":1337,"foo":"bar"}`),
+				resource.TestCheckResourceAttrSet(datasourceAddress, "computed_values"),
+				resource.TestCheckResourceAttrSet(datasourceAddress, "notes"),
+				resource.TestCheckResourceAttrSet(datasourceAddress, "manifest"),
+			),
+		}},
+	})
+}
+
+func TestSetDataReleaseAttributes(t *testing.T) {
+	r := &release.Release{
+		Name:      "example",
+		Namespace: "default",
+		Version:   2,
+		Info: &release.Info{
+			Status: release.StatusDeployed,
+			Notes:  "notes",
+		},
+		Chart: &chart.Chart{
+			Metadata: &chart.Metadata{Name: "example-chart", Version: "1.0.0", AppVersion: "2.0.0"},
+			Values:   map[string]interface{}{"replicas": 1, "image": "nginx"},
+		},
+		Config: map[string]interface{}{"replicas": 3},
+		Manifest: `---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: example
+data:
+  password: c2VjcmV0
+`,
+	}
+
+	d := schema.TestResourceDataRaw(t, dataRelease().Schema, map[string]interface{}{"name": "example"})
+	if err := setDataReleaseAttributes(d, r); err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Id() != "default/example" {
+		t.Fatalf("unexpected id %q", d.Id())
+	}
+
+	expected := map[string]interface{}{
+		"chart":           "example-chart",
+		"version":         "1.0.0",
+		"app_version":     "2.0.0",
+		"revision":        2,
+		"values":          `{"replicas":3}`,
+		"computed_values": `{"image":"nginx","replicas":3}`,
+	}
+
+	for k, v := range expected {
+		if d.Get(k) != v {
+			t.Errorf("expected %s to be %v, got %v", k, v, d.Get(k))
+		}
+	}
+
+	if manifest := d.Get("manifest").(string); strings.Contains(manifest, "c2VjcmV0") {
+		t.Errorf("secret data was not redacted from the manifest: %s", manifest)
+	}
+}
+
+func testAccDataHelmReleaseConfigBasic(resource, ns, name, version string) string {
+	return fmt.Sprintf(`
+		resource "helm_release" "%s" {
+			name        = %q
+			namespace   = %q
+			repository  = %q
+			chart       = "test-chart"
+			version     = %q
+
+			set {
+				name = "foo"
+				value = "bar"
+			}
+
+			set {
+				name = "fizz"
+				value = 1337
+			}
+		}
+
+		data "helm_release" "%s" {
+			name      = helm_release.%s.name
+			namespace = helm_release.%s.namespace
+		}
+	`, resource, name, ns, testRepositoryURL, version, resource, resource, resource)
+}
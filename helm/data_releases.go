@@ -0,0 +1,195 @@
+package helm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// releaseStatuses are the statuses that releases can be filtered by
+var releaseStatuses = []string{
+	release.StatusDeployed.String(),
+	release.StatusFailed.String(),
+	release.StatusPendingInstall.String(),
+	release.StatusPendingUpgrade.String(),
+	release.StatusPendingRollback.String(),
+	release.StatusSuperseded.String(),
+	release.StatusUninstalled.String(),
+	release.StatusUninstalling.String(),
+}
+
+func dataReleases() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataReleasesRead,
+		Schema: map[string]*schema.Schema{
+			"namespace": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Namespace to list the releases of. Ignored if all_namespaces is set.",
+				DefaultFunc: schema.EnvDefaultFunc("HELM_NAMESPACE", "default"),
+			},
+			"all_namespaces": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "List the releases across all namespaces.",
+			},
+			"filter": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+				Description:  "Regular expression the names of the releases have to match.",
+			},
+			"statuses": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "Statuses the releases have to be in. By default, deployed and failed releases are listed.",
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringInSlice(releaseStatuses, false),
+				},
+			},
+			"chart": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Name of the chart the releases have to be installed from.",
+			},
+			"releases": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The releases matching the filters.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Name is the name of the release.",
+						},
+						"namespace": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Namespace is the kubernetes namespace of the release.",
+						},
+						"revision": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Version is an int32 which represents the version of the release.",
+						},
+						"chart": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The name of the chart.",
+						},
+						"version": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "A SemVer 2 conformant version string of the chart.",
+						},
+						"app_version": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The version number of the application being deployed.",
+						},
+						"status": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Status of the release.",
+						},
+						"updated": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The time the release was last deployed.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataReleasesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	logID := "[dataReleasesRead]"
+	debug("%s Started", logID)
+
+	m := meta.(*Meta)
+
+	n := d.Get("namespace").(string)
+	allNamespaces := d.Get("all_namespaces").(bool)
+	if allNamespaces {
+		// An empty namespace makes the storage driver look into all of them
+		n = ""
+	}
+
+	actionConfig, err := m.GetHelmConfiguration(n)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	statuses := expandStringSlice(d.Get("statuses").(*schema.Set).List())
+
+	client := action.NewList(actionConfig)
+	client.AllNamespaces = allNamespaces
+	client.Filter = d.Get("filter").(string)
+	client.StateMask = listStateMask(statuses)
+
+	debug("%s Listing releases", logID)
+	releases, err := client.Run()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	chartName := d.Get("chart").(string)
+
+	d.SetId(fmt.Sprintf("%s/%s/%s/%s", n, client.Filter, strings.Join(statuses, ","), chartName))
+
+	if err := d.Set("releases", flattenReleases(releases, chartName)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	debug("%s Done", logID)
+
+	return nil
+}
+
+// listStateMask returns the mask to list releases in the given statuses,
+// defaulting to deployed and failed releases like `helm list`
+func listStateMask(statuses []string) action.ListStates {
+	if len(statuses) == 0 {
+		return action.ListDeployed | action.ListFailed
+	}
+
+	var mask action.ListStates
+	for _, s := range statuses {
+		mask |= mask.FromName(s)
+	}
+	return mask
+}
+
+func flattenReleases(releases []*release.Release, chartName string) []map[string]interface{} {
+	result := []map[string]interface{}{}
+
+	for _, r := range releases {
+		if chartName != "" && r.Chart.Metadata.Name != chartName {
+			continue
+		}
+
+		result = append(result, map[string]interface{}{
+			"name":        r.Name,
+			"namespace":   r.Namespace,
+			"revision":    r.Version,
+			"chart":       r.Chart.Metadata.Name,
+			"version":     r.Chart.Metadata.Version,
+			"app_version": r.Chart.Metadata.AppVersion,
+			"status":      r.Info.Status.String(),
+			"updated":     formatTime(r.Info.LastDeployed),
+		})
+	}
+
+	return result
+}
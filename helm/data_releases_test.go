@@ -0,0 +1,100 @@
+package helm
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+func TestAccDataReleases_basic(t *testing.T) {
+	name := randName("data-releases")
+	namespace := createRandomNamespace(t)
+	defer deleteNamespace(t, namespace)
+
+	datasourceAddress := fmt.Sprintf("data.helm_releases.%s", testResourceName)
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckHelmReleaseDestroy(namespace),
+		Steps: []resource.TestStep{{
+			Config: testAccDataHelmReleasesConfigBasic(testResourceName, namespace, name),
+			Check: resource.ComposeAggregateTestCheckFunc(
+				resource.TestCheckResourceAttr(datasourceAddress, "releases.#", "1"),
+				resource.TestCheckResourceAttr(datasourceAddress, "releases.0.name", name),
+				resource.TestCheckResourceAttr(datasourceAddress, "releases.0.namespace", namespace),
+				resource.TestCheckResourceAttr(datasourceAddress, "releases.0.revision", "1"),
+				resource.TestCheckResourceAttr(datasourceAddress, "releases.0.chart", "test-chart"),
+				resource.TestCheckResourceAttr(datasourceAddress, "releases.0.version", "1.2.3"),
+				resource.TestCheckResourceAttr(datasourceAddress, "releases.0.status", release.StatusDeployed.String()),
+				resource.TestCheckResourceAttrSet(datasourceAddress, "releases.0.updated"),
+			),
+		}},
+	})
+}
+
+func TestListStateMask(t *testing.T) {
+	if mask := listStateMask(nil); mask != action.ListDeployed|action.ListFailed {
+		t.Errorf("unexpected default mask %v", mask)
+	}
+
+	mask := listStateMask([]string{"superseded", "pending-upgrade"})
+	if mask != action.ListSuperseded|action.ListPendingUpgrade {
+		t.Errorf("unexpected mask %v", mask)
+	}
+}
+
+func TestFlattenReleases(t *testing.T) {
+	newRelease := func(name, chartName string) *release.Release {
+		return &release.Release{
+			Name:      name,
+			Namespace: "default",
+			Version:   1,
+			Info:      &release.Info{Status: release.StatusDeployed},
+			Chart: &chart.Chart{
+				Metadata: &chart.Metadata{Name: chartName, Version: "1.0.0", AppVersion: "2.0.0"},
+			},
+		}
+	}
+
+	releases := []*release.Release{
+		newRelease("first", "nginx"),
+		newRelease("second", "redis"),
+	}
+
+	if result := flattenReleases(releases, ""); len(result) != 2 {
+		t.Fatalf("expected 2 releases, got %d", len(result))
+	}
+
+	result := flattenReleases(releases, "redis")
+	if len(result) != 1 || result[0]["name"] != "second" {
+		t.Fatalf("unexpected releases %v", result)
+	}
+
+	if result[0]["updated"] != "" {
+		t.Errorf("expected no updated time, got %q", result[0]["updated"])
+	}
+}
+
+func testAccDataHelmReleasesConfigBasic(resource, ns, name string) string {
+	return fmt.Sprintf(`
+		resource "helm_release" "%s" {
+			name        = %q
+			namespace   = %q
+			repository  = %q
+			chart       = "test-chart"
+			version     = "1.2.3"
+		}
+
+		data "helm_releases" "%s" {
+			namespace = helm_release.%s.namespace
+			filter    = "^${helm_release.%s.name}$"
+			chart     = "test-chart"
+		}
+	`, resource, name, ns, testRepositoryURL, resource, resource, resource)
+}
@@ -4,28 +4,38 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"github.com/Masterminds/semver/v3"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/chartutil"
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	"helm.sh/helm/v3/pkg/postrender"
 	"helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/releaseutil"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sigs.k8s.io/yaml"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
 
 // defaultTemplateAttributes template attribute values
 var defaultTemplateAttributes = map[string]interface{}{
-	"validate":     false,
-	"include_crds": false,
-	"is_upgrade":   false,
-	"skip_tests":   false,
+	"validate":               false,
+	"include_crds":           false,
+	"exclude_hooks_and_crds": false,
+	"is_upgrade":             false,
+	"skip_tests":             false,
 }
 
 func dataTemplate() *schema.Resource {
@@ -72,7 +82,7 @@ func dataTemplate() *schema.Resource {
 			"chart": {
 				Type:        schema.TypeString,
 				Required:    true,
-				Description: "Chart name to be installed. A path may be used.",
+				Description: "Chart name to be installed. A path, a URL or an `oci://` reference may be used.",
 			},
 			"version": {
 				Type:        schema.TypeString,
@@ -95,6 +105,12 @@ func dataTemplate() *schema.Resource {
 				Description: "List of values in raw yaml format to pass to helm.",
 				Elem:        &schema.Schema{Type: schema.TypeString},
 			},
+			"values_files": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "List of paths, or glob patterns, of values files in yaml format to merge before the values.",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
 			"set": {
 				Type:        schema.TypeSet,
 				Optional:    true,
@@ -115,7 +131,7 @@ func dataTemplate() *schema.Resource {
 							// TODO: use ValidateDiagFunc once an SDK v2 version of StringInSlice exists.
 							// https://github.com/hashicorp/terraform-plugin-sdk/issues/534
 							ValidateFunc: validation.StringInSlice([]string{
-								"auto", "string",
+								"auto", "string", "json", "literal",
 							}, false),
 						},
 					},
@@ -140,12 +156,81 @@ func dataTemplate() *schema.Resource {
 							Type:     schema.TypeString,
 							Optional: true,
 							ValidateFunc: validation.StringInSlice([]string{
-								"auto", "string",
+								"auto", "string", "json", "literal",
 							}, false),
 						},
 					},
 				},
 			},
+			"set_list": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Custom list values to be merged with the values.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"value": {
+							Type:     schema.TypeList,
+							Required: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+			"set_file": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "Custom values read from files to be merged with the values. Like the other values, they end up in the rendered manifests.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"path": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+					},
+				},
+			},
+			"set_from_env": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "Custom values read from environment variables each time the data source is read. Like the other values, they end up in the rendered manifests.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"env": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+					},
+				},
+			},
+			"set_from_file": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "Custom values read from files each time the data source is read. Like the other values, they end up in the rendered manifests.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"path": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+					},
+				},
+			},
 			"set_string": {
 				Type:        schema.TypeSet,
 				Optional:    true,
@@ -292,15 +377,26 @@ func dataTemplate() *schema.Resource {
 			"api_versions": {
 				Type:        schema.TypeList,
 				Optional:    true,
-				Description: "Kubernetes api versions used for Capabilities.APIVersions",
+				Description: "Kubernetes api versions used for Capabilities.APIVersions. Used only if `validate` is false",
 				Elem:        &schema.Schema{Type: schema.TypeString},
 			},
+			"kube_version": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Kubernetes version used for Capabilities.KubeVersion. Used only if `validate` is false, defaults to the version known to Helm.",
+			},
 			"include_crds": {
 				Type:        schema.TypeBool,
 				Optional:    true,
 				Default:     defaultTemplateAttributes["include_crds"],
 				Description: "Include CRDs in the templated output",
 			},
+			"exclude_hooks_and_crds": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     defaultTemplateAttributes["exclude_hooks_and_crds"],
+				Description: "Leave hooks and CRDs out of the manifests, they are only exposed in hooks and crds. include_crds is ignored if set",
+			},
 			"is_upgrade": {
 				Type:        schema.TypeBool,
 				Optional:    true,
@@ -319,6 +415,20 @@ func dataTemplate() *schema.Resource {
 				Default:     defaultTemplateAttributes["validate"],
 				Description: "Validate your manifests against the Kubernetes cluster you are currently pointing at. This is the same validation performed on an install",
 			},
+			"lookup_mode": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Where the lookup function finds objects: `fixtures` for the lookup_objects, `cluster` for the Kubernetes cluster or `disabled`. Defaults to `fixtures` if lookup_objects is set, `disabled` otherwise",
+				ValidateFunc: validation.StringInSlice([]string{
+					lookupModeDisabled, lookupModeFixtures, lookupModeCluster,
+				}, false),
+			},
+			"lookup_objects": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Kubernetes objects returned by the lookup function, either YAML or JSON documents or the path of a directory holding .yaml, .yml and .json files",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
 			"manifests": {
 				Type:        schema.TypeMap,
 				Optional:    true,
@@ -334,6 +444,126 @@ func dataTemplate() *schema.Resource {
 				Computed:    true,
 				Description: "Concatenated rendered chart templates. This corresponds to the output of the `helm template` command.",
 			},
+			"resources": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The Kubernetes objects rendered from the chart templates, one entry per object.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"key": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The key of the object, as used in the `manifest` of `helm_release`.",
+						},
+						"api_version": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The API version of the object.",
+						},
+						"kind": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The kind of the object.",
+						},
+						"namespace": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The namespace of the object, if set in its metadata.",
+						},
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The name of the object.",
+						},
+						"source": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The chart template the object was rendered from.",
+						},
+						"hook_events": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "The hook events of the object, if it is a hook.",
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+						"json": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The object, JSON encoded.",
+						},
+					},
+				},
+			},
+			"hooks": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The hooks rendered from the chart templates, sorted by weight and name. The hooks of an event are run by Helm in this order, filter them by `events` to get the ones of an event.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The name of the hook object.",
+						},
+						"kind": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The kind of the hook object.",
+						},
+						"path": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The chart template the hook was rendered from.",
+						},
+						"events": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "The events the hook runs on.",
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+						"weight": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "The weight of the hook, ordering the hooks of an event.",
+						},
+						"delete_policies": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "The policies deciding when the hook object is deleted.",
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+						"manifest": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The rendered manifest of the hook.",
+						},
+					},
+				},
+			},
+			"crds": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The CRDs in the crds/ directories of the chart and its dependencies.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The name of the CRD file, relative to its chart.",
+						},
+						"path": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The path of the CRD file, including the path of its chart.",
+						},
+						"manifest": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The content of the CRD file.",
+						},
+					},
+				},
+			},
 			"notes": {
 				Type:        schema.TypeString,
 				Optional:    true,
@@ -375,11 +605,24 @@ func dataTemplateRead(ctx context.Context, d *schema.ResourceData, meta interfac
 
 	debug("%s Getting Config", logID)
 
-	actionConfig, err := m.GetHelmConfiguration(n)
+	actionConfig, err := templateConfiguration(d, m, n, apiVersions)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
+	lookupMode, err := templateLookupMode(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	renderConfig := actionConfig
+	if lookupMode != lookupModeDisabled {
+		renderConfig, err = lookupConfiguration(d, m, n, lookupMode, actionConfig)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
 	cpo, chartName, err := chartPathOptions(d, m)
 	if err != nil {
 		return diag.FromErr(err)
@@ -410,12 +653,21 @@ func dataTemplateRead(ctx context.Context, d *schema.ResourceData, meta interfac
 		return diag.FromErr(err)
 	}
 
+	isUpgrade := d.Get("is_upgrade").(bool)
+	if isUpgrade && d.Get("reuse_values").(bool) && !d.Get("reset_values").(bool) {
+		debug("%s Reusing the values of the release", logID)
+		values, err = reuseReleaseValues(m, n, name, c, values)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
 	err = isChartInstallable(c)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	client := action.NewInstall(actionConfig)
+	client := action.NewInstall(renderConfig)
 	client.ChartPathOptions = *cpo
 	client.ClientOnly = false
 	client.DryRun = true
@@ -437,30 +689,57 @@ func dataTemplateRead(ctx context.Context, d *schema.ResourceData, meta interfac
 	client.Description = d.Get("description").(string)
 	client.CreateNamespace = d.Get("create_namespace").(bool)
 
+	if cmd := d.Get("postrender.0.binary_path").(string); cmd != "" {
+		pr, err := postrender.NewExec(cmd)
+
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		client.PostRenderer = pr
+	}
+
 	// The following source has been adapted from the source of the helm template command
 	// https://github.com/helm/helm/blob/v3.5.3/cmd/helm/template.go#L67
 	client.DryRun = true
 	// NOTE Do not set fixed release name as client.ReleaseName like in helm template command
 	client.Replace = true // Skip the name check
-	client.ClientOnly = !d.Get("validate").(bool)
-	client.APIVersions = chartutil.VersionSet(apiVersions)
-	client.IncludeCRDs = d.Get("include_crds").(bool)
+	// NOTE Unlike the helm template command, client only mode is never used as it replaces
+	// the capabilities with the defaults. Without validation, templateConfiguration
+	// provides a configuration which does not access the cluster instead.
+	client.ClientOnly = false
+	excludeHooksAndCRDs := d.Get("exclude_hooks_and_crds").(bool)
+	client.IncludeCRDs = d.Get("include_crds").(bool) && !excludeHooksAndCRDs
+	client.IsUpgrade = isUpgrade
 
 	skipTests := d.Get("skip_tests").(bool)
 
 	debug("%s Rendering Chart", logID)
 
-	rel, err := client.Run(c, values)
+	var rel *release.Release
+	if lookupMode == lookupModeDisabled {
+		rel, err = client.Run(c, values)
+	} else {
+		rel, err = renderWithLookups(renderConfig, client, c, values)
+	}
 
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
+	// Rendering with lookups does not validate against the cluster
+	if lookupMode != lookupModeDisabled && d.Get("validate").(bool) {
+		_, err := actionConfig.KubeClient.Build(bytes.NewBufferString(rel.Manifest), !client.DisableOpenAPIValidation)
+		if err != nil {
+			return diag.Errorf("unable to build kubernetes objects from release manifest: %s", err)
+		}
+	}
+
 	var manifests bytes.Buffer
 
 	fmt.Fprintln(&manifests, strings.TrimSpace(rel.Manifest))
 
-	if !client.DisableHooks {
+	if !client.DisableHooks && !excludeHooksAndCRDs {
 		for _, m := range rel.Hooks {
 			if skipTests && isTestHook(m) {
 				continue
@@ -532,11 +811,27 @@ func dataTemplateRead(ctx context.Context, d *schema.ResourceData, meta interfac
 	// Map from rendered manifests to data source output
 	computedManifests := make(map[string]string, 0)
 	computedManifest := &strings.Builder{}
+	computedResources := []map[string]interface{}{}
 
 	for _, manifestKey := range manifestsToRender {
 		manifest := splitManifests[manifestKey]
 		manifestName := manifestNamesByKey[manifestKey]
 
+		// Resources
+		resource, err := flattenTemplateResource(manifest, manifestName)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if resource != nil {
+			computedResources = append(computedResources, resource)
+
+			// Post renderers may drop the comments naming the templates,
+			// such manifests are named after the object they hold
+			if manifestName == "" {
+				manifestName = resource["key"].(string)
+			}
+		}
+
 		// Manifests
 		computedManifests[manifestName] = manifest
 
@@ -558,6 +853,21 @@ func dataTemplateRead(ctx context.Context, d *schema.ResourceData, meta interfac
 		return diag.FromErr(err)
 	}
 
+	err = d.Set("resources", computedResources)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	err = d.Set("hooks", flattenTemplateHooks(rel.Hooks, skipTests))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	err = d.Set("crds", flattenTemplateCRDs(c.CRDObjects()))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	err = d.Set("notes", computedNotes)
 	if err != nil {
 		return diag.FromErr(err)
@@ -566,6 +876,179 @@ func dataTemplateRead(ctx context.Context, d *schema.ResourceData, meta interfac
 	return nil
 }
 
+// flattenTemplateResource returns the resources entry of the object rendered
+// into the manifest, or nil if the manifest holds no object
+func flattenTemplateResource(manifest, source string) (map[string]interface{}, error) {
+	resourceMeta := resourceMeta{}
+	if err := yaml.Unmarshal([]byte(manifest), &resourceMeta); err != nil {
+		return nil, fmt.Errorf("could not parse manifest rendered from %s: %v", source, err)
+	}
+
+	if resourceMeta.Kind == "" {
+		return nil, nil
+	}
+
+	jsonbytes, err := yaml.YAMLToJSON([]byte(manifest))
+	if err != nil {
+		return nil, fmt.Errorf("could not convert manifest to JSON: %v", err)
+	}
+
+	hookEvents := []string{}
+	if hooks, ok := resourceMeta.Metadata.Annotations[release.HookAnnotation]; ok {
+		for _, event := range strings.Split(hooks, ",") {
+			if event = strings.TrimSpace(event); event != "" {
+				hookEvents = append(hookEvents, event)
+			}
+		}
+	}
+
+	return map[string]interface{}{
+		"key":         resourceMeta.key(),
+		"api_version": resourceMeta.APIVersion,
+		"kind":        resourceMeta.Kind,
+		"namespace":   resourceMeta.Metadata.Namespace,
+		"name":        resourceMeta.Metadata.Name,
+		"source":      source,
+		"hook_events": hookEvents,
+		"json":        string(jsonbytes),
+	}, nil
+}
+
+// flattenTemplateHooks returns the hooks sorted by weight and name. Helm sorts
+// the hooks of each event the same way before running them, the hooks of an
+// event are run in the order they appear in the list.
+func flattenTemplateHooks(hooks []*release.Hook, skipTests bool) []map[string]interface{} {
+	result := []map[string]interface{}{}
+
+	sorted := append([]*release.Hook{}, hooks...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Weight == sorted[j].Weight {
+			return sorted[i].Name < sorted[j].Name
+		}
+		return sorted[i].Weight < sorted[j].Weight
+	})
+
+	for _, h := range sorted {
+		if skipTests && isTestHook(h) {
+			continue
+		}
+
+		events := make([]string, 0, len(h.Events))
+		for _, e := range h.Events {
+			events = append(events, e.String())
+		}
+
+		deletePolicies := make([]string, 0, len(h.DeletePolicies))
+		for _, p := range h.DeletePolicies {
+			deletePolicies = append(deletePolicies, p.String())
+		}
+
+		result = append(result, map[string]interface{}{
+			"name":            h.Name,
+			"kind":            h.Kind,
+			"path":            h.Path,
+			"events":          events,
+			"weight":          h.Weight,
+			"delete_policies": deletePolicies,
+			"manifest":        h.Manifest,
+		})
+	}
+
+	return result
+}
+
+func flattenTemplateCRDs(crds []chart.CRD) []map[string]interface{} {
+	result := []map[string]interface{}{}
+
+	for _, crd := range crds {
+		result = append(result, map[string]interface{}{
+			"name":     crd.Name,
+			"path":     filepath.ToSlash(crd.Filename),
+			"manifest": string(crd.File.Data),
+		})
+	}
+
+	return result
+}
+
+// reuseReleaseValues merges the values of the last revision of the release
+// into the values, the way an upgrade reusing the values does. The values are
+// left as they are if the release does not exist.
+func reuseReleaseValues(m *Meta, namespace, name string, c *chart.Chart, values map[string]interface{}) (map[string]interface{}, error) {
+	actionConfig, err := m.GetHelmConfiguration(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := getRelease(m, actionConfig, name)
+	if err == errReleaseNotFound {
+		return values, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Adapted from the reuseValues method of the upgrade action
+	// https://github.com/helm/helm/blob/v3.5.3/pkg/action/upgrade.go#L434
+	oldValues, err := chartutil.CoalesceValues(r.Chart, r.Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rebuild old values: %s", err)
+	}
+
+	c.Values = oldValues
+
+	return chartutil.CoalesceTables(values, r.Config), nil
+}
+
+// templateConfiguration returns the Helm configuration to render the chart
+// with. Unless the manifests are validated against the cluster, the chart is
+// rendered without any access to it, with the given capabilities.
+func templateConfiguration(d resourceGetter, m *Meta, namespace string, apiVersions []string) (*action.Configuration, error) {
+	if d.Get("validate").(bool) {
+		return m.GetHelmConfiguration(namespace)
+	}
+
+	capabilities, err := templateCapabilities(d.Get("kube_version").(string), apiVersions)
+	if err != nil {
+		return nil, err
+	}
+
+	mem := driver.NewMemory()
+	mem.SetNamespace(namespace)
+
+	return &action.Configuration{
+		Releases:     storage.Init(mem),
+		KubeClient:   &kubefake.PrintingKubeClient{Out: ioutil.Discard},
+		Capabilities: capabilities,
+		Log:          debug,
+	}, nil
+}
+
+// templateCapabilities returns the default capabilities of Helm, with the
+// given Kubernetes version and additional api versions
+func templateCapabilities(kubeVersion string, apiVersions []string) (*chartutil.Capabilities, error) {
+	capabilities := *chartutil.DefaultCapabilities
+
+	// copy the default version set, as appending to it may modify it
+	capabilities.APIVersions = append(chartutil.VersionSet{}, chartutil.DefaultVersionSet...)
+	capabilities.APIVersions = append(capabilities.APIVersions, apiVersions...)
+
+	if kubeVersion != "" {
+		v, err := semver.NewVersion(kubeVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kube_version %q: %s", kubeVersion, err)
+		}
+
+		capabilities.KubeVersion = chartutil.KubeVersion{
+			Version: "v" + v.String(),
+			Major:   strconv.FormatUint(v.Major(), 10),
+			Minor:   strconv.FormatUint(v.Minor(), 10),
+		}
+	}
+
+	return &capabilities, nil
+}
+
 func isTestHook(h *release.Hook) bool {
 	for _, e := range h.Events {
 		if e == release.HookTest {
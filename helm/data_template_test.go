@@ -1,10 +1,18 @@
 package helm
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"helm.sh/helm/v3/pkg/chartutil"
 )
 
 func TestAccDataTemplate_basic(t *testing.T) {
@@ -26,6 +34,10 @@ func TestAccDataTemplate_basic(t *testing.T) {
 				resource.TestCheckResourceAttrSet(datasourceAddress, "manifests.templates/tests/test-connection.yaml"),
 				resource.TestCheckResourceAttrSet(datasourceAddress, "manifest"),
 				resource.TestCheckResourceAttrSet(datasourceAddress, "notes"),
+				resource.TestCheckResourceAttr(datasourceAddress, "resources.#", "4"),
+				resource.TestCheckResourceAttr(datasourceAddress, "resources.3.kind", "Pod"),
+				resource.TestCheckResourceAttr(datasourceAddress, "resources.3.source", "templates/tests/test-connection.yaml"),
+				resource.TestCheckResourceAttr(datasourceAddress, "resources.3.hook_events.0", "test-success"),
 			),
 		}},
 	})
@@ -52,6 +64,58 @@ func TestAccDataTemplate_templates(t *testing.T) {
 	})
 }
 
+func TestAccDataTemplate_upgrade(t *testing.T) {
+	name := randName("upgrade")
+	namespace := createRandomNamespace(t)
+	defer deleteNamespace(t, namespace)
+
+	datasourceAddress := fmt.Sprintf("data.helm_template.%s", testResourceName)
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{{
+			Config: testAccDataHelmTemplateConfigUpgrade(testResourceName, namespace, name),
+			Check: resource.ComposeAggregateTestCheckFunc(
+				resource.TestMatchResourceAttr(datasourceAddress, "manifests.templates/service.yaml", regexp.MustCompile("port: 1337")),
+				resource.TestMatchResourceAttr(datasourceAddress, "manifests.templates/deployment.yaml", regexp.MustCompile("replicas: 2")),
+			),
+		}},
+	})
+}
+
+func testAccDataHelmTemplateConfigUpgrade(resource, ns, name string) string {
+	return fmt.Sprintf(`
+		resource "helm_release" "%[1]s" {
+			name       = %[2]q
+			namespace  = %[3]q
+			repository = %[4]q
+			chart      = "test-chart"
+			version    = "1.2.3"
+
+			set {
+				name  = "service.port"
+				value = 1337
+			}
+		}
+
+		data "helm_template" "%[1]s" {
+			name         = helm_release.%[1]s.name
+			namespace    = %[3]q
+			repository   = %[4]q
+			chart        = "test-chart"
+			version      = "1.2.3"
+			is_upgrade   = true
+			reuse_values = true
+
+			set {
+				name  = "replicaCount"
+				value = 2
+			}
+		}
+	`, resource, name, ns, testRepositoryURL)
+}
+
 func testAccDataHelmTemplateConfigBasic(resource, ns, name, version string) string {
 	return fmt.Sprintf(`
 		data "helm_template" "%s" {
@@ -101,3 +165,323 @@ func testAccDataHelmTemplateConfigTemplates(resource, ns, name, version string)
 		}
 	`, resource, name, ns, testRepositoryURL, version)
 }
+
+func TestFlattenTemplateResource(t *testing.T) {
+	manifest := `# Source: test-chart/templates/hooks.yaml
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+  namespace: apps
+  annotations:
+    "helm.sh/hook": pre-install, pre-upgrade
+spec:
+  template:
+    spec:
+      restartPolicy: Never
+`
+
+	resource, err := flattenTemplateResource(manifest, "templates/hooks.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"key":         "apps/job.batch/batch/v1/migrate",
+		"api_version": "batch/v1",
+		"kind":        "Job",
+		"namespace":   "apps",
+		"name":        "migrate",
+		"source":      "templates/hooks.yaml",
+		"hook_events": []string{"pre-install", "pre-upgrade"},
+		"json":        `{"apiVersion":"batch/v1","kind":"Job","metadata":{"annotations":{"helm.sh/hook":"pre-install, pre-upgrade"},"name":"migrate","namespace":"apps"},"spec":{"template":{"spec":{"restartPolicy":"Never"}}}}`,
+	}
+
+	if !reflect.DeepEqual(resource, expected) {
+		t.Errorf("expected %v, got %v", expected, resource)
+	}
+
+	// Manifests rendering no object have no resource
+	resource, err = flattenTemplateResource("# Source: test-chart/templates/empty.yaml\n", "templates/empty.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resource != nil {
+		t.Errorf("expected no resource, got %v", resource)
+	}
+}
+
+func TestDataTemplateRead_offline(t *testing.T) {
+	// The test meta has no kubernetes configuration, rendering must not
+	// access the cluster
+	m := newTestMeta(t)
+
+	d := schema.TestResourceDataRaw(t, dataTemplate().Schema, map[string]interface{}{
+		"name":         "offline",
+		"chart":        filepath.Join(testChartsPath, "schema-chart"),
+		"kube_version": "1.21.3",
+		"api_versions": []interface{}{"example.com/v1"},
+	})
+
+	if diags := dataTemplateRead(context.Background(), d, m); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	manifest := d.Get("manifests").(map[string]interface{})["templates/configmap.yaml"].(string)
+	for _, expected := range []string{`kubeVersion: "v1.21.3"`, `example: "enabled"`} {
+		if !strings.Contains(manifest, expected) {
+			t.Errorf("expected manifest to contain %q, got %q", expected, manifest)
+		}
+	}
+
+	// The kubeVersion of the chart is checked against kube_version
+	d = schema.TestResourceDataRaw(t, dataTemplate().Schema, map[string]interface{}{
+		"name":         "offline",
+		"chart":        filepath.Join(testChartsPath, "schema-chart"),
+		"kube_version": "1.15.0",
+	})
+
+	diags := dataTemplateRead(context.Background(), d, m)
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "incompatible with Kubernetes v1.15.0") {
+		t.Errorf("expected an incompatible kubeVersion error, got %v", diags)
+	}
+}
+
+func TestTemplateCapabilities(t *testing.T) {
+	capabilities, err := templateCapabilities("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(capabilities.KubeVersion, chartutil.DefaultCapabilities.KubeVersion) {
+		t.Errorf("expected the default kube version, got %v", capabilities.KubeVersion)
+	}
+
+	capabilities, err = templateCapabilities("v1.19.4", []string{"example.com/v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := chartutil.KubeVersion{Version: "v1.19.4", Major: "1", Minor: "19"}
+	if capabilities.KubeVersion != expected {
+		t.Errorf("expected kube version %v, got %v", expected, capabilities.KubeVersion)
+	}
+
+	if !capabilities.APIVersions.Has("example.com/v1") || !capabilities.APIVersions.Has("v1") {
+		t.Errorf("unexpected api versions %v", capabilities.APIVersions)
+	}
+
+	if chartutil.DefaultCapabilities.APIVersions.Has("example.com/v1") {
+		t.Error("expected the default capabilities to be left untouched")
+	}
+
+	if _, err := templateCapabilities("latest", nil); err == nil {
+		t.Error("expected an error for an invalid kube version")
+	}
+}
+
+func TestDataTemplateRead_postrender(t *testing.T) {
+	dir := t.TempDir()
+
+	postrenderers := map[string]string{
+		"rename":        "#!/bin/sh\nsed 's/-schema-chart$/-post-rendered/'\n",
+		"strip-sources": "#!/bin/sh\ngrep -v '^# Source:'\n",
+	}
+
+	for name, script := range postrenderers {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := newTestMeta(t)
+
+	d := schema.TestResourceDataRaw(t, dataTemplate().Schema, map[string]interface{}{
+		"name":       "postrender",
+		"chart":      filepath.Join(testChartsPath, "schema-chart"),
+		"postrender": []interface{}{map[string]interface{}{"binary_path": filepath.Join(dir, "rename")}},
+	})
+
+	if diags := dataTemplateRead(context.Background(), d, m); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	manifest := d.Get("manifests").(map[string]interface{})["templates/configmap.yaml"].(string)
+	if !strings.Contains(manifest, "name: postrender-post-rendered") {
+		t.Errorf("expected the manifest to be post rendered, got %q", manifest)
+	}
+
+	if d.Get("resources.0.name") != "postrender-post-rendered" {
+		t.Errorf("expected the resources to be post rendered, got %v", d.Get("resources"))
+	}
+
+	// Manifests whose template is unknown are named after their object
+	d = schema.TestResourceDataRaw(t, dataTemplate().Schema, map[string]interface{}{
+		"name":       "postrender",
+		"chart":      filepath.Join(testChartsPath, "schema-chart"),
+		"postrender": []interface{}{map[string]interface{}{"binary_path": filepath.Join(dir, "strip-sources")}},
+	})
+
+	if diags := dataTemplateRead(context.Background(), d, m); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	if _, ok := d.Get("manifests").(map[string]interface{})["configmap/v1/postrender-schema-chart"]; !ok {
+		t.Errorf("expected the manifest to be named after its object, got %v", d.Get("manifests"))
+	}
+}
+
+func TestDataTemplateRead_hooksAndCRDs(t *testing.T) {
+	m := newTestMeta(t)
+
+	d := schema.TestResourceDataRaw(t, dataTemplate().Schema, map[string]interface{}{
+		"name":         "hooks",
+		"chart":        filepath.Join(testChartsPath, "hooks-chart"),
+		"include_crds": true,
+	})
+
+	if diags := dataTemplateRead(context.Background(), d, m); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	if n := len(d.Get("manifests").(map[string]interface{})); n != 4 {
+		t.Errorf("expected the hooks and CRDs in the manifests, got %d manifests", n)
+	}
+
+	expected := map[string]interface{}{
+		"hooks.#":                 2,
+		"hooks.0.name":            "hooks-migrate",
+		"hooks.0.kind":            "Job",
+		"hooks.0.path":            "hooks-chart/templates/migrate.yaml",
+		"hooks.0.events.#":        2,
+		"hooks.0.events.1":        "pre-upgrade",
+		"hooks.0.weight":          -5,
+		"hooks.0.delete_policies": []interface{}{"before-hook-creation", "hook-succeeded"},
+		"hooks.1.events":          []interface{}{"test"},
+		"crds.#":                  1,
+		"crds.0.name":             "crds/crontab.yaml",
+		"crds.0.path":             "hooks-chart/crds/crontab.yaml",
+	}
+
+	for k, v := range expected {
+		if !reflect.DeepEqual(d.Get(k), v) {
+			t.Errorf("expected %s to be %v, got %v", k, v, d.Get(k))
+		}
+	}
+
+	if !strings.Contains(d.Get("hooks.0.manifest").(string), "kind: Job") {
+		t.Errorf("unexpected hook manifest %q", d.Get("hooks.0.manifest"))
+	}
+
+	if !strings.Contains(d.Get("crds.0.manifest").(string), "kind: CustomResourceDefinition") {
+		t.Errorf("unexpected CRD manifest %q", d.Get("crds.0.manifest"))
+	}
+
+	// Hooks and CRDs are left out of the manifests
+	d = schema.TestResourceDataRaw(t, dataTemplate().Schema, map[string]interface{}{
+		"name":                   "hooks",
+		"chart":                  filepath.Join(testChartsPath, "hooks-chart"),
+		"include_crds":           true,
+		"skip_tests":             true,
+		"exclude_hooks_and_crds": true,
+	})
+
+	if diags := dataTemplateRead(context.Background(), d, m); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	manifests := d.Get("manifests").(map[string]interface{})
+	if _, ok := manifests["templates/configmap.yaml"]; !ok || len(manifests) != 1 {
+		t.Errorf("expected only the configmap in the manifests, got %v", manifests)
+	}
+
+	if d.Get("hooks.#") != 1 || d.Get("crds.#") != 1 {
+		t.Errorf("expected the hooks but the tests and the CRDs, got %v and %v", d.Get("hooks"), d.Get("crds"))
+	}
+}
+
+func TestDataTemplateRead_isUpgrade(t *testing.T) {
+	m := newTestMeta(t)
+
+	for _, isUpgrade := range []bool{false, true} {
+		d := schema.TestResourceDataRaw(t, dataTemplate().Schema, map[string]interface{}{
+			"name":       "upgrade",
+			"chart":      filepath.Join(testChartsPath, "hooks-chart"),
+			"is_upgrade": isUpgrade,
+		})
+
+		if diags := dataTemplateRead(context.Background(), d, m); diags.HasError() {
+			t.Fatal(diags)
+		}
+
+		manifest := d.Get("manifests").(map[string]interface{})["templates/configmap.yaml"].(string)
+		for _, expected := range []string{
+			fmt.Sprintf("isInstall: %q", fmt.Sprint(!isUpgrade)),
+			fmt.Sprintf("isUpgrade: %q", fmt.Sprint(isUpgrade)),
+		} {
+			if !strings.Contains(manifest, expected) {
+				t.Errorf("expected manifest to contain %s, got %q", expected, manifest)
+			}
+		}
+	}
+}
+
+func TestDataTemplateRead_lookup(t *testing.T) {
+	m := newTestMeta(t)
+
+	secret := `
+apiVersion: v1
+kind: Secret
+metadata:
+  name: lookup-credentials
+  namespace: default
+data:
+  password: c2VjcmV0
+`
+
+	cases := map[string]struct {
+		attributes map[string]interface{}
+		expected   []string
+	}{
+		"fixtures": {
+			attributes: map[string]interface{}{
+				"lookup_objects": []interface{}{secret, "./testdata/lookup"},
+			},
+			expected: []string{"password: c2VjcmV0", `configMaps: "2"`, `team: "platform"`, `isUpgrade: "false"`},
+		},
+		"upgrade": {
+			attributes: map[string]interface{}{
+				"lookup_objects": []interface{}{secret},
+				"is_upgrade":     true,
+			},
+			expected: []string{"password: c2VjcmV0", `configMaps: "0"`, `team: "none"`, `isUpgrade: "true"`},
+		},
+		"disabled": {
+			attributes: map[string]interface{}{},
+			expected:   []string{`configMaps: "0"`, `team: "none"`, `isUpgrade: "false"`},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tc.attributes["name"] = "lookup"
+			tc.attributes["chart"] = filepath.Join(testChartsPath, "lookup-chart")
+
+			d := schema.TestResourceDataRaw(t, dataTemplate().Schema, tc.attributes)
+
+			if diags := dataTemplateRead(context.Background(), d, m); diags.HasError() {
+				t.Fatal(diags)
+			}
+
+			manifest := d.Get("manifest").(string)
+			for _, expected := range tc.expected {
+				if !strings.Contains(manifest, expected) {
+					t.Errorf("expected manifest to contain %s, got %q", expected, manifest)
+				}
+			}
+
+			if name == "disabled" && strings.Contains(manifest, "c2VjcmV0") {
+				t.Errorf("expected a generated password, got %q", manifest)
+			}
+		})
+	}
+}
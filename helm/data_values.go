@@ -0,0 +1,319 @@
+package helm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"sigs.k8s.io/yaml"
+)
+
+func dataValues() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataValuesRead,
+		Schema: map[string]*schema.Schema{
+			"values": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "List of values in raw yaml format to merge.",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"values_files": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "List of paths, or glob patterns, of values files in yaml format to merge before the values.",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"set": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "Custom values to be merged with the values.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"value": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"type": {
+							Type:     schema.TypeString,
+							Optional: true,
+							ValidateFunc: validation.StringInSlice([]string{
+								"auto", "string", "json", "literal",
+							}, false),
+						},
+					},
+				},
+			},
+			"set_sensitive": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "Custom sensitive values to be merged with the values.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"value": {
+							Type:      schema.TypeString,
+							Required:  true,
+							Sensitive: true,
+						},
+						"type": {
+							Type:     schema.TypeString,
+							Optional: true,
+							ValidateFunc: validation.StringInSlice([]string{
+								"auto", "string", "json", "literal",
+							}, false),
+						},
+					},
+				},
+			},
+			"set_list": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Custom list values to be merged with the values.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"value": {
+							Type:     schema.TypeList,
+							Required: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+			"set_file": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "Custom values read from files to be merged with the values. The contents of the files are cloaked.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"path": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+					},
+				},
+			},
+			"set_from_env": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "Custom sensitive values read from environment variables when the values are computed. They are not stored in the state.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"env": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+					},
+				},
+			},
+			"set_from_file": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "Custom sensitive values read from files when the values are computed. They are not stored in the state.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"path": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+					},
+				},
+			},
+			"repository": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Repository where to locate the requested chart. If is a URL the chart is installed without installing the repository.",
+			},
+			"repository_key_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The repositories cert key file",
+			},
+			"repository_cert_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The repositories cert file",
+			},
+			"repository_ca_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The Repositories CA File",
+			},
+			"repository_username": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Username for HTTP basic authentication",
+			},
+			"repository_password": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				Description: "Password for HTTP basic authentication",
+			},
+			"chart": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Chart whose default values the values are coalesced with. A path, a URL or an `oci://` reference may be used.",
+			},
+			"version": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Specify the exact chart version to use. If this is not specified, the latest version is used.",
+			},
+			"devel": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Use chart development versions, too. Equivalent to version '>0.0.0-0'. If `version` is set, this is ignored",
+			},
+			"verify": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     defaultAttributes["verify"],
+				Description: "Verify the package before using it.",
+			},
+			"keyring": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     os.ExpandEnv("$HOME/.gnupg/pubring.gpg"),
+				Description: "Location of public keys used for verification. Used only if `verify` is true",
+			},
+			"merged_yaml": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The user-supplied values merged together, in YAML.",
+			},
+			"merged_json": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The user-supplied values merged together, JSON encoded.",
+			},
+			"coalesced_yaml": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The merged values coalesced with the default values of the chart, in YAML. Only set if `chart` is set.",
+			},
+			"coalesced_json": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The merged values coalesced with the default values of the chart, JSON encoded. Only set if `chart` is set.",
+			},
+		},
+	}
+}
+
+func dataValuesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	logID := "[dataValuesRead]"
+	debug("%s Started", logID)
+
+	m := meta.(*Meta)
+
+	values, err := getValues(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	mergedYAML, mergedJSON, err := marshalCloakedValues(values, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	coalescedYAML, coalescedJSON := "", ""
+	if d.Get("chart").(string) != "" {
+		cpo, chartName, err := chartPathOptions(d, m)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		debug("%s Getting chart", logID)
+		c, _, err := getChart(d, m, chartName, cpo)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		coalesced, err := chartutil.CoalesceValues(c, values)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		coalescedYAML, coalescedJSON, err = marshalCloakedValues(coalesced, d)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		if err := d.Set("version", c.Metadata.Version); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	sum := sha256.Sum256([]byte(mergedJSON + coalescedJSON))
+	d.SetId(hex.EncodeToString(sum[:]))
+
+	attributes := map[string]interface{}{
+		"merged_yaml":    mergedYAML,
+		"merged_json":    mergedJSON,
+		"coalesced_yaml": coalescedYAML,
+		"coalesced_json": coalescedJSON,
+	}
+
+	for k, v := range attributes {
+		if err := d.Set(k, v); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	debug("%s Done", logID)
+
+	return nil
+}
+
+// marshalCloakedValues returns the values in YAML and JSON, with the
+// sensitive values cloaked
+func marshalCloakedValues(values map[string]interface{}, d resourceGetter) (string, string, error) {
+	c, err := cloakValues(values, d)
+	if err != nil {
+		return "", "", err
+	}
+
+	y, err := yaml.Marshal(c)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal values to YAML: %s", err)
+	}
+
+	j, err := json.Marshal(c)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal values to JSON: %s", err)
+	}
+
+	return string(y), string(j), nil
+}
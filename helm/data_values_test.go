@@ -0,0 +1,106 @@
+package helm
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestAccDataValues_basic(t *testing.T) {
+	datasourceAddress := fmt.Sprintf("data.helm_values.%s", testResourceName)
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{{
+			Config: testAccDataHelmValuesConfig(testResourceName, testRepositoryURL, "test-chart"),
+			Check: resource.ComposeAggregateTestCheckFunc(
+				resource.TestCheckResourceAttr(datasourceAddress, "merged_json", `{"password":"(sensitive value)","service":{"type":"NodePort"}}`),
+				resource.TestCheckResourceAttr(datasourceAddress, "version", "2.0.0"),
+				resource.TestCheckResourceAttrSet(datasourceAddress, "coalesced_yaml"),
+			),
+		}},
+	})
+}
+
+func testAccDataHelmValuesConfig(resource, repository, chart string) string {
+	return fmt.Sprintf(`
+		data "helm_values" "%s" {
+			repository = %q
+			chart      = %q
+
+			values = [<<-EOT
+				service:
+				  type: ClusterIP
+				EOT
+			]
+
+			set {
+				name  = "service.type"
+				value = "NodePort"
+			}
+
+			set_sensitive {
+				name  = "password"
+				value = "hunter2"
+			}
+		}
+	`, resource, repository, chart)
+}
+
+func TestDataValuesRead(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, dataValues().Schema, map[string]interface{}{
+		"chart":  filepath.Join(testChartsPath, "schema-chart"),
+		"values": []interface{}{"replicaCount: 2\nimage:\n  tag: latest\n"},
+		"set": []interface{}{
+			map[string]interface{}{"name": "service.port", "value": "8080"},
+		},
+		"set_sensitive": []interface{}{
+			map[string]interface{}{"name": "image.pullSecret", "value": "secret"},
+		},
+	})
+
+	if diags := dataValuesRead(context.Background(), d, newTestMeta(t)); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	expected := map[string]interface{}{
+		"merged_json":    `{"image":{"pullSecret":"(sensitive value)","tag":"latest"},"replicaCount":2,"service":{"port":8080}}`,
+		"coalesced_json": `{"image":{"pullSecret":"(sensitive value)","repository":"nginx","tag":"latest"},"replicaCount":2,"service":{"port":8080}}`,
+		"version":        "0.1.0",
+	}
+
+	for k, v := range expected {
+		if d.Get(k) != v {
+			t.Errorf("expected %s to be %v, got %v", k, v, d.Get(k))
+		}
+	}
+
+	if d.Get("merged_yaml") != "image:\n  pullSecret: (sensitive value)\n  tag: latest\nreplicaCount: 2\nservice:\n  port: 8080\n" {
+		t.Errorf("unexpected merged_yaml %q", d.Get("merged_yaml"))
+	}
+}
+
+func TestDataValuesRead_withoutChart(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, dataValues().Schema, map[string]interface{}{
+		"set": []interface{}{
+			map[string]interface{}{"name": "tag", "value": "1.0", "type": "string"},
+		},
+	})
+
+	if diags := dataValuesRead(context.Background(), d, newTestMeta(t)); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	if d.Get("merged_json") != `{"tag":"1.0"}` {
+		t.Errorf("unexpected merged_json %q", d.Get("merged_json"))
+	}
+
+	if d.Get("coalesced_json") != "" {
+		t.Errorf("expected no coalesced values without a chart, got %q", d.Get("coalesced_json"))
+	}
+}
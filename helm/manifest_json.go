@@ -1,8 +1,10 @@
 package helm
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"strings"
 
 	"golang.org/x/crypto/sha3"
@@ -21,6 +23,20 @@ type resourceMeta struct {
 	Metadata metav1.ObjectMeta
 }
 
+// key identifies the object in a manifest, as
+// [namespace/]kind.group/apiVersion/name
+func (r resourceMeta) key() string {
+	gvk := r.GetObjectKind().GroupVersionKind()
+	key := fmt.Sprintf("%s/%s/%s", strings.ToLower(gvk.GroupKind().String()),
+		r.APIVersion,
+		r.Metadata.Name)
+
+	if namespace := r.Metadata.Namespace; namespace != "" {
+		key = fmt.Sprintf("%s/%s", namespace, key)
+	}
+	return key
+}
+
 func convertYAMLManifestToJSON(manifest string) (string, error) {
 	m := map[string]json.RawMessage{}
 
@@ -37,16 +53,9 @@ func convertYAMLManifestToJSON(manifest string) (string, error) {
 			return "", err
 		}
 
-		gvk := resourceMeta.GetObjectKind().GroupVersionKind()
-		key := fmt.Sprintf("%s/%s/%s", strings.ToLower(gvk.GroupKind().String()),
-			resourceMeta.APIVersion,
-			resourceMeta.Metadata.Name)
-
-		if namespace := resourceMeta.Metadata.Namespace; namespace != "" {
-			key = fmt.Sprintf("%s/%s", namespace, key)
-		}
+		key := resourceMeta.key()
 
-		if gvk.Kind == "Secret" {
+		if resourceMeta.Kind == "Secret" {
 			secret := corev1.Secret{}
 			err = yaml.Unmarshal([]byte(resource), &secret)
 			if err != nil {
@@ -86,19 +95,118 @@ func hashSensitiveValue(v string) string {
 	return fmt.Sprintf("(sensitive value %x)", hash)
 }
 
-// redactSensitiveValues removes values that appear in `set_sensitive` blocks from
-// the manifest JSON
-func redactSensitiveValues(text string, d resourceGetter) string {
+// redactSensitiveValues removes values that appear in `set_sensitive`,
+// `set_file`, `set_from_env` and `set_from_file` blocks from the manifest JSON
+func redactSensitiveValues(text string, d resourceGetter) (string, error) {
 	masked := text
 
 	for _, v := range d.Get("set_sensitive").(*schema.Set).List() {
 		vv := v.(map[string]interface{})
 
-		if sensitiveValue, ok := vv["value"].(string); ok {
-			h := hashSensitiveValue(sensitiveValue)
-			masked = strings.ReplaceAll(masked, sensitiveValue, h)
+		sensitiveValue, ok := vv["value"].(string)
+		if !ok {
+			continue
+		}
+
+		if vv["type"] != "json" {
+			masked = strings.ReplaceAll(masked, sensitiveValue, hashSensitiveValue(sensitiveValue))
+			continue
+		}
+
+		// JSON values are not rendered as given, their strings are redacted instead
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(sensitiveValue), &decoded); err != nil {
+			continue
+		}
+		for _, s := range jsonStrings(decoded) {
+			masked = strings.ReplaceAll(masked, s, sensitiveContentValue)
+		}
+	}
+
+	// The values of set_from_env and set_from_file are also redacted when
+	// base64 encoded, as they are in secrets
+	setFromValues, err := getSetFromValues(d)
+	if err != nil {
+		return "", err
+	}
+	for _, v := range setFromValues {
+		if v != "" {
+			masked = redactEncodedValue(masked, v)
+		}
+	}
+
+	// The contents of the set_file files are redacted the same way, as they
+	// may be rendered in secrets too
+	for _, raw := range d.Get("set_file").(*schema.Set).List() {
+		set := raw.(map[string]interface{})
+		path := set["path"].(string)
+		if path == "" {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		masked = redactFileContents(masked, string(data))
+	}
+
+	return masked, nil
+}
+
+// redactFileContents removes the contents of a file from the manifest JSON.
+// Multi-line contents are escaped in the JSON, and their trailing newline is
+// often trimmed by the templates.
+func redactFileContents(text, contents string) string {
+	masked := text
+
+	for _, v := range []string{contents, strings.TrimRight(contents, "\n")} {
+		if v == "" {
+			continue
+		}
+
+		masked = redactEncodedValue(masked, v)
+
+		escaped, err := json.Marshal(v)
+		if err != nil {
+			continue
+		}
+		if e := string(escaped[1 : len(escaped)-1]); e != v {
+			masked = strings.ReplaceAll(masked, e, sensitiveContentValue)
 		}
 	}
 
 	return masked
 }
+
+// redactEncodedValue removes the value from the manifest JSON, also when
+// base64 encoded. The value is replaced with a placeholder rather than a
+// hash, as the values read from the environment, files and Secrets may be
+// easy to guess from an unsalted hash.
+func redactEncodedValue(text, v string) string {
+	masked := strings.ReplaceAll(text, v, sensitiveContentValue)
+	encoded := base64.StdEncoding.EncodeToString([]byte(v))
+	return strings.ReplaceAll(masked, encoded, sensitiveContentValue)
+}
+
+// jsonStrings returns the non-empty strings of a decoded JSON value
+func jsonStrings(v interface{}) []string {
+	var result []string
+
+	switch vv := v.(type) {
+	case string:
+		if vv != "" {
+			result = append(result, vv)
+		}
+	case []interface{}:
+		for _, e := range vv {
+			result = append(result, jsonStrings(e)...)
+		}
+	case map[string]interface{}:
+		for _, e := range vv {
+			result = append(result, jsonStrings(e)...)
+		}
+	}
+
+	return result
+}
@@ -16,6 +16,7 @@ import (
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/cli"
 	"helm.sh/helm/v3/pkg/helmpath"
+	"helm.sh/helm/v3/pkg/repo"
 	"helm.sh/helm/v3/pkg/storage/driver"
 
 	// Import to initialize client auth plugins.
@@ -28,9 +29,19 @@ type Meta struct {
 	Settings   *cli.EnvSettings
 	HelmDriver string
 
+	// Registries to access using plain HTTP
+	insecureRegistries map[string]bool
+
+	// Credentials of the configured registries, by host
+	registryLogins map[string]*registryLogin
+
 	// Used to lock some operations
 	sync.Mutex
 
+	// Repository indexes downloaded into the cache in this run, by
+	// repository name. Guarded by the lock.
+	repositoryIndexes map[string]*repo.IndexFile
+
 	// Experimental feature toggles
 	experiments map[string]bool
 }
@@ -98,6 +109,12 @@ func Provider() *schema.Provider {
 					}
 				},
 			},
+			"registry": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "OCI registries to login to. The credentials are stored in the registry config file.",
+				Elem:        registryResource(),
+			},
 			"kubernetes": {
 				Type:        schema.TypeList,
 				MaxItems:    1,
@@ -132,10 +149,22 @@ func Provider() *schema.Provider {
 			},
 		},
 		ResourcesMap: map[string]*schema.Resource{
-			"helm_release": resourceRelease(),
+			"helm_chart_package":    resourceChartPackage(),
+			"helm_chart_push":       resourceChartPush(),
+			"helm_plugin":           resourcePlugin(),
+			"helm_release":          resourceRelease(),
+			"helm_release_test":     resourceReleaseTesting(),
+			"helm_repository":       resourceRepository(),
+			"helm_repository_index": resourceRepositoryIndex(),
 		},
 		DataSourcesMap: map[string]*schema.Resource{
-			"helm_template": dataTemplate(),
+			"helm_chart":           dataChart(),
+			"helm_chart_versions":  dataChartVersions(),
+			"helm_release":         dataRelease(),
+			"helm_release_history": dataReleaseHistory(),
+			"helm_releases":        dataReleases(),
+			"helm_template":        dataTemplate(),
+			"helm_values":          dataValues(),
 		},
 	}
 	p.ConfigureContextFunc = func(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
@@ -144,6 +173,41 @@ func Provider() *schema.Provider {
 	return p
 }
 
+func registryResource() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"host": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The hostname of the registry, including the port if any.",
+			},
+			"username": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The username to use to login to the registry.",
+			},
+			"password": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				Description: "The password to use to login to the registry.",
+			},
+			"token": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				Description: "The identity token to use to login to the registry, instead of a password.",
+			},
+			"insecure": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Access the registry using plain HTTP or without verifying its TLS certificate.",
+			},
+		},
+	}
+}
+
 func kubernetesResource() *schema.Resource {
 	return &schema.Resource{
 		Schema: map[string]*schema.Schema{
@@ -259,7 +323,9 @@ func kubernetesResource() *schema.Resource {
 
 func providerConfigure(d *schema.ResourceData, terraformVersion string) (interface{}, diag.Diagnostics) {
 	m := &Meta{
-		data: d,
+		data:               d,
+		insecureRegistries: map[string]bool{},
+		registryLogins:     map[string]*registryLogin{},
 		experiments: map[string]bool{
 			"manifest": d.Get("experiments.0.manifest").(bool),
 		},
@@ -288,6 +354,10 @@ func providerConfigure(d *schema.ResourceData, terraformVersion string) (interfa
 
 	m.Settings = settings
 
+	if err := configureRegistries(m, d.Get("registry").([]interface{})); err != nil {
+		return nil, diag.FromErr(err)
+	}
+
 	if v, ok := d.GetOk("helm_driver"); ok {
 		m.HelmDriver = v.(string)
 	}
@@ -0,0 +1,266 @@
+package helm
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/deislabs/oras/pkg/auth"
+	dockerauth "github.com/deislabs/oras/pkg/auth/docker"
+	"github.com/deislabs/oras/pkg/content"
+	orascontext "github.com/deislabs/oras/pkg/context"
+	"github.com/deislabs/oras/pkg/oras"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+
+	"helm.sh/helm/v3/pkg/action"
+)
+
+// The following media types and the pull logic are adapted from the
+// experimental registry client of Helm, which is internal to the Helm module
+// https://github.com/helm/helm/blob/v3.5.3/internal/experimental/registry/client.go
+const (
+	ociScheme = "oci://"
+
+	helmChartConfigMediaType       = "application/vnd.cncf.helm.config.v1+json"
+	helmChartContentLayerMediaType = "application/tar+gzip"
+)
+
+// registryClient pulls and pushes charts from and to OCI registries using the
+// credentials stored in the registry config file of the provider
+type registryClient struct {
+	authorizer auth.Client
+	plainHTTP  bool
+}
+
+func newRegistryClient(m *Meta, host string) (*registryClient, error) {
+	if err := os.MkdirAll(filepath.Dir(m.Settings.RegistryConfig), os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	c, err := dockerauth.NewClient(m.Settings.RegistryConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &registryClient{
+		authorizer: c,
+		plainHTTP:  m.insecureRegistries[host],
+	}
+
+	if l, ok := m.registryLogins[host]; ok {
+		if err := l.login(client, host); err != nil {
+			return nil, err
+		}
+	}
+
+	return client, nil
+}
+
+// registryLogin holds the credentials of a registry configured in the
+// provider. They are only used to log in when a chart is first pulled from or
+// pushed to the registry, not to reach it when no OCI chart is used.
+type registryLogin struct {
+	username string
+	password string
+	insecure bool
+
+	once sync.Once
+	err  error
+}
+
+func (l *registryLogin) login(c *registryClient, host string) error {
+	l.once.Do(func() {
+		debug("[INFO] Logging in to registry %s", host)
+		if err := c.Login(host, l.username, l.password, l.insecure); err != nil {
+			l.err = errors.Wrapf(err, "could not login to registry %q", host)
+		}
+	})
+	return l.err
+}
+
+// Login verifies the credentials against the registry and stores them in the
+// registry config file
+func (c *registryClient) Login(host, username, password string, insecure bool) error {
+	return c.authorizer.Login(orascontext.Background(), host, username, password, insecure)
+}
+
+// PullChart downloads the chart archive referenced by ref, in the form
+// registry/repository:tag, and returns its content
+func (c *registryClient) PullChart(ref string) ([]byte, error) {
+	ctx := orascontext.Background()
+	resolver, err := c.authorizer.Resolver(ctx, http.DefaultClient, c.plainHTTP)
+	if err != nil {
+		return nil, err
+	}
+
+	store := content.NewMemoryStore()
+	_, layers, err := oras.Pull(ctx, resolver, ref, store,
+		oras.WithPullEmptyNameAllowed(),
+		oras.WithAllowedMediaTypes([]string{helmChartConfigMediaType, helmChartContentLayerMediaType}))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to pull %q", ref)
+	}
+
+	var contentLayer *ocispec.Descriptor
+	for _, layer := range layers {
+		layer := layer
+		if layer.MediaType == helmChartContentLayerMediaType {
+			contentLayer = &layer
+		}
+	}
+
+	if contentLayer == nil {
+		return nil, errors.Errorf("manifest of %q does not contain a layer with mediatype %s", ref, helmChartContentLayerMediaType)
+	}
+
+	_, b, ok := store.Get(*contentLayer)
+	if !ok {
+		return nil, errors.Errorf("unable to retrieve blob with digest %s", contentLayer.Digest)
+	}
+
+	return b, nil
+}
+
+// PushChart uploads the chart archive to the registry as ref, in the form
+// registry/repository:tag, with the chart metadata as config, and returns the
+// descriptor of the manifest
+func (c *registryClient) PushChart(ref string, archive, metadata []byte) (ocispec.Descriptor, error) {
+	ctx := orascontext.Background()
+	resolver, err := c.authorizer.Resolver(ctx, http.DefaultClient, c.plainHTTP)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+
+	store := content.NewMemoryStore()
+	config := store.Add("", helmChartConfigMediaType, metadata)
+	layer := store.Add("", helmChartContentLayerMediaType, archive)
+
+	manifest, err := oras.Push(ctx, resolver, ref, store, []ocispec.Descriptor{layer},
+		oras.WithConfig(config),
+		oras.WithNameValidation(nil))
+	if err != nil {
+		return ocispec.Descriptor{}, errors.Wrapf(err, "failed to push %q", ref)
+	}
+
+	return manifest, nil
+}
+
+// configureRegistries reads the registries configured in the provider, their
+// credentials are stored in the registry config file on the first login
+func configureRegistries(m *Meta, registries []interface{}) error {
+	for _, raw := range registries {
+		r := raw.(map[string]interface{})
+
+		host := r["host"].(string)
+		username := r["username"].(string)
+		password := r["password"].(string)
+		insecure := r["insecure"].(bool)
+
+		if token := r["token"].(string); token != "" {
+			if password != "" {
+				return fmt.Errorf("only one of password or token can be set for registry %q", host)
+			}
+			// The token is stored as an identity token when no username is given
+			password = token
+		}
+
+		if insecure {
+			m.insecureRegistries[host] = true
+		}
+
+		m.registryLogins[host] = &registryLogin{
+			username: username,
+			password: password,
+			insecure: insecure,
+		}
+	}
+	return nil
+}
+
+func isOCIChart(name string) bool {
+	return strings.HasPrefix(name, ociScheme)
+}
+
+// ociChartReference builds the registry reference of an oci:// chart name. The
+// version is mandatory as it is used as the tag of the chart, registries can
+// not be searched for the versions matching a constraint.
+func ociChartReference(name, version string) (string, error) {
+	ref := strings.TrimPrefix(name, ociScheme)
+	if version == "" {
+		return "", fmt.Errorf("a chart version is required to pull %q from an OCI registry", name)
+	}
+
+	if _, err := semver.NewVersion(version); err != nil {
+		if _, err := semver.NewConstraint(version); err == nil {
+			return "", fmt.Errorf("version constraint %q is not supported for %q, charts from OCI registries require an exact version", version, name)
+		}
+	}
+
+	if i := strings.LastIndex(ref, "/"); i == -1 || strings.Contains(ref[i:], ":") {
+		return "", fmt.Errorf("invalid OCI chart %q, expected oci://<registry>/<repository> with the tag set in the version attribute", name)
+	}
+
+	return fmt.Sprintf("%s:%s", ref, version), nil
+}
+
+// locateOCIChart pulls an oci:// chart into the repository cache and returns
+// the path of the archive
+func locateOCIChart(m *Meta, name string, cpo *action.ChartPathOptions) (string, error) {
+	if cpo.Verify {
+		return "", fmt.Errorf("chart verification is not supported for OCI charts")
+	}
+
+	ref, err := ociChartReference(name, cpo.Version)
+	if err != nil {
+		return "", err
+	}
+
+	c, err := newRegistryClient(m, strings.SplitN(ref, "/", 2)[0])
+	if err != nil {
+		return "", err
+	}
+
+	b, err := c.PullChart(ref)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(m.Settings.RepositoryCache, 0755); err != nil {
+		return "", err
+	}
+
+	// The archives are cached by digest, the same name and version may be
+	// pulled from different registries
+	filename := filepath.Join(m.Settings.RepositoryCache, fmt.Sprintf("%s-%s-%x.tgz", filepath.Base(name), cpo.Version, sha256.Sum256(b)))
+	if _, err := os.Stat(filename); err == nil {
+		return filename, nil
+	}
+
+	// It is written to a temporary file first, not to be read half written
+	// by the releases pulling the same chart in parallel
+	f, err := ioutil.TempFile(m.Settings.RepositoryCache, filepath.Base(filename)+".*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		return "", err
+	}
+
+	return filename, os.Rename(f.Name(), filename)
+}
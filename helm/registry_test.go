@@ -0,0 +1,325 @@
+package helm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+)
+
+// testRegistry is an in-memory stand-in for a registry:2 server, implementing
+// the parts of the distribution API used to pull and push charts
+type testRegistry struct {
+	*httptest.Server
+
+	sync.Mutex
+	blobs     map[digest.Digest][]byte
+	manifests map[string][]byte
+}
+
+func newTestRegistry(t *testing.T) *testRegistry {
+	r := &testRegistry{
+		blobs:     map[digest.Digest][]byte{},
+		manifests: map[string][]byte{},
+	}
+	r.Server = httptest.NewServer(http.HandlerFunc(r.serveHTTP))
+	t.Cleanup(r.Close)
+	return r
+}
+
+// Host returns the host of the registry, as used in oci:// references
+func (r *testRegistry) Host() string {
+	return strings.TrimPrefix(r.URL, "http://")
+}
+
+func (r *testRegistry) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	r.Lock()
+	defer r.Unlock()
+
+	if req.URL.Path == "/v2/" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if req.Method == http.MethodPost || req.Method == http.MethodPut {
+		r.serveUpload(w, req)
+		return
+	}
+
+	var (
+		b         []byte
+		ok        bool
+		mediaType = "application/octet-stream"
+	)
+
+	switch {
+	case strings.Contains(req.URL.Path, "/manifests/"):
+		parts := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/v2/"), "/manifests/", 2)
+		b, ok = r.manifests[parts[0]+":"+parts[1]]
+		if !ok {
+			b, ok = r.blobs[digest.Digest(parts[1])]
+		}
+		mediaType = ocispec.MediaTypeImageManifest
+	case strings.Contains(req.URL.Path, "/blobs/"):
+		parts := strings.SplitN(req.URL.Path, "/blobs/", 2)
+		b, ok = r.blobs[digest.Digest(parts[1])]
+	}
+
+	if !ok || (req.Method != http.MethodGet && req.Method != http.MethodHead) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("Content-Length", fmt.Sprint(len(b)))
+	w.Header().Set("Docker-Content-Digest", digest.FromBytes(b).String())
+	if req.Method == http.MethodGet {
+		w.Write(b)
+	}
+}
+
+// serveUpload stores monolithic blob uploads and manifests
+func (r *testRegistry) serveUpload(w http.ResponseWriter, req *http.Request) {
+	switch {
+	case req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/blobs/uploads/"):
+		w.Header().Set("Location", req.URL.Path+fmt.Sprint(len(r.blobs)))
+		w.WriteHeader(http.StatusAccepted)
+	case req.Method == http.MethodPut && strings.Contains(req.URL.Path, "/blobs/uploads/"):
+		b, err := ioutil.ReadAll(req.Body)
+		d := digest.Digest(req.URL.Query().Get("digest"))
+		if err != nil || d != digest.FromBytes(b) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		r.blobs[d] = b
+		w.Header().Set("Docker-Content-Digest", d.String())
+		w.WriteHeader(http.StatusCreated)
+	case req.Method == http.MethodPut && strings.Contains(req.URL.Path, "/manifests/"):
+		b, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		parts := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/v2/"), "/manifests/", 2)
+		d := digest.FromBytes(b)
+		r.manifests[parts[0]+":"+parts[1]] = b
+		r.blobs[d] = b
+		w.Header().Set("Docker-Content-Digest", d.String())
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (r *testRegistry) addBlob(b []byte) ocispec.Descriptor {
+	d := digest.FromBytes(b)
+	r.blobs[d] = b
+	return ocispec.Descriptor{Digest: d, Size: int64(len(b))}
+}
+
+// addChart packages the chart in path and stores it as repository:tag
+func (r *testRegistry) addChart(t *testing.T, path, repository, tag string) {
+	r.Lock()
+	defer r.Unlock()
+
+	c, err := loader.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := chartutil.Save(c, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metadata, err := json.Marshal(c.Metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := r.addBlob(metadata)
+	config.MediaType = helmChartConfigMediaType
+	layer := r.addBlob(content)
+	layer.MediaType = helmChartContentLayerMediaType
+
+	manifest, err := json.Marshal(ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		Config:    config,
+		Layers:    []ocispec.Descriptor{layer},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.manifests[repository+":"+tag] = manifest
+	r.blobs[digest.FromBytes(manifest)] = manifest
+}
+
+func newTestMeta(t *testing.T) *Meta {
+	home := t.TempDir()
+
+	settings := cli.New()
+	settings.RegistryConfig = filepath.Join(home, "config/registry.json")
+	settings.RepositoryConfig = filepath.Join(home, "config/repositories.yaml")
+	settings.RepositoryCache = filepath.Join(home, "cache/helm/repository")
+	settings.PluginsDirectory = filepath.Join(home, "data/helm/plugins")
+
+	return &Meta{
+		Settings:           settings,
+		insecureRegistries: map[string]bool{},
+		registryLogins:     map[string]*registryLogin{},
+	}
+}
+
+func TestLocateOCIChart(t *testing.T) {
+	r := newTestRegistry(t)
+	r.addChart(t, filepath.Join(testChartsPath, "test-chart"), "charts/test-chart", "1.2.3")
+
+	m := newTestMeta(t)
+	name := fmt.Sprintf("oci://%s/charts/test-chart", r.Host())
+
+	path, err := locateChart(m, name, &action.ChartPathOptions{Version: "1.2.3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := loader.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Metadata.Name != "test-chart" || c.Metadata.Version != "1.2.3" {
+		t.Fatalf("unexpected chart %s-%s", c.Metadata.Name, c.Metadata.Version)
+	}
+
+	if _, err := locateChart(m, name, &action.ChartPathOptions{Version: "9.9.9"}); err == nil {
+		t.Fatal("expected an error pulling a missing tag")
+	}
+
+	// The same name and version from another registry does not replace the
+	// chart pulled first
+	other := newTestRegistry(t)
+	other.addChart(t, filepath.Join(testChartsPath, "test-chart-v2"), "charts/test-chart", "1.2.3")
+
+	otherPath, err := locateChart(m, fmt.Sprintf("oci://%s/charts/test-chart", other.Host()), &action.ChartPathOptions{Version: "1.2.3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if otherPath == path {
+		t.Fatalf("expected the charts of the registries to be cached apart, got %s", path)
+	}
+
+	c, err = loader.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Metadata.Name != "test-chart" || c.Metadata.Version != "1.2.3" {
+		t.Fatalf("expected the chart pulled first to be kept, got %s-%s", c.Metadata.Name, c.Metadata.Version)
+	}
+}
+
+func TestOCIChartReference(t *testing.T) {
+	tests := []struct {
+		name, version, expected string
+		fails                   bool
+	}{
+		{"oci://registry.local/charts/app", "1.0.0", "registry.local/charts/app:1.0.0", false},
+		{"oci://localhost:5000/app", "0.1.0", "localhost:5000/app:0.1.0", false},
+		{"oci://registry.local/charts/app", "", "", true},
+		{"oci://registry.local/charts/app:1.0.0", "1.0.0", "", true},
+		{"oci://localhost:5000", "1.0.0", "", true},
+		{"oci://registry.local/charts/app", "latest", "registry.local/charts/app:latest", false},
+		{"oci://registry.local/charts/app", "~1.0", "", true},
+		{"oci://registry.local/charts/app", ">= 1.0.0, < 2.0.0", "", true},
+	}
+
+	for _, tt := range tests {
+		ref, err := ociChartReference(tt.name, tt.version)
+		if tt.fails {
+			if err == nil {
+				t.Errorf("expected an error for %q, got reference %q", tt.name, ref)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("unexpected error for %q: %s", tt.name, err)
+		}
+
+		if ref != tt.expected {
+			t.Errorf("expected reference %q, got %q", tt.expected, ref)
+		}
+	}
+}
+
+func TestResolveChartNameOCI(t *testing.T) {
+	repositoryURL, name, err := resolveChartName("oci://registry.local/charts/", "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if repositoryURL != "" || name != "oci://registry.local/charts/app" {
+		t.Fatalf("unexpected resolution %q %q", repositoryURL, name)
+	}
+}
+
+func TestConfigureRegistries(t *testing.T) {
+	m := newTestMeta(t)
+
+	// The registry is unreachable, which does not fail the configuration of
+	// the provider as it is only logged in to when a chart is pulled
+	host := "127.0.0.1:1"
+	err := configureRegistries(m, []interface{}{map[string]interface{}{
+		"host":     host,
+		"username": "user",
+		"password": "",
+		"token":    "token",
+		"insecure": true,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !m.insecureRegistries[host] {
+		t.Fatalf("expected %s to be an insecure registry", host)
+	}
+
+	if _, err := newRegistryClient(m, host); err == nil || !strings.Contains(err.Error(), "could not login") {
+		t.Fatalf("expected the login to fail, got %v", err)
+	}
+
+	// Other registries do not need to log in
+	if _, err := newRegistryClient(m, "registry.local"); err != nil {
+		t.Fatal(err)
+	}
+
+	err = configureRegistries(m, []interface{}{map[string]interface{}{
+		"host":     host,
+		"username": "",
+		"password": "password",
+		"token":    "token",
+		"insecure": false,
+	}})
+	if err == nil {
+		t.Fatal("expected an error when both password and token are set")
+	}
+}
@@ -0,0 +1,433 @@
+package helm
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/downloader"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/provenance"
+)
+
+func resourceChartPackage() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceChartPackageCreate,
+		ReadContext:   resourceChartPackageRead,
+		DeleteContext: resourceChartPackageDelete,
+		CustomizeDiff: resourceChartPackageDiff,
+		Schema: map[string]*schema.Schema{
+			"path": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Path of the chart directory to package.",
+			},
+			"destination": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Directory to write the chart archive to.",
+			},
+			"version": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Set the version of the chart to this SemVer version.",
+			},
+			"app_version": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Set the appVersion of the chart to this version.",
+			},
+			"dependency_update": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				ForceNew:    true,
+				Default:     false,
+				Description: "Update the dependencies of the chart from Chart.yaml into the charts/ directory before packaging.",
+			},
+			"sign": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				ForceNew:    true,
+				MaxItems:    1,
+				Description: "Sign the archive with a PGP private key, writing a provenance file next to it.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"key": {
+							Type:        schema.TypeString,
+							Required:    true,
+							ForceNew:    true,
+							Description: "Name of the key to use when signing.",
+						},
+						"keyring": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							ForceNew:    true,
+							Default:     os.ExpandEnv("$HOME/.gnupg/secring.gpg"),
+							Description: "Location of the keyring holding the private key.",
+						},
+						"passphrase": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							ForceNew:    true,
+							Sensitive:   true,
+							Description: "Passphrase of the private key, if it is encrypted.",
+						},
+					},
+				},
+			},
+			"source_digest": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				ForceNew:    true,
+				Description: "The SHA256 digest of the files of the chart, used to package the chart again when they change.",
+			},
+			"archive_path": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Path of the chart archive.",
+			},
+			"provenance_path": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Path of the provenance file, if the archive is signed.",
+			},
+			"sha256": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The SHA256 digest of the chart archive. The archive is written with fixed modification times, packaging the same chart gives the same digest.",
+			},
+			"metadata": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The metadata of the packaged chart.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The name of the chart.",
+						},
+						"version": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "A SemVer 2 conformant version string of the chart.",
+						},
+						"app_version": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The version number of the application packaged by the chart.",
+						},
+						"type": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The type of the chart, application or library.",
+						},
+						"description": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "A one-sentence description of the chart.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func resourceChartPackageCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	logID := fmt.Sprintf("[resourceChartPackageCreate: %s]", d.Get("path").(string))
+	debug("%s Started", logID)
+
+	m := meta.(*Meta)
+	path := d.Get("path").(string)
+
+	if d.Get("dependency_update").(bool) {
+		debug("%s Updating chart dependencies", logID)
+		if err := updateChartDependencies(m, path); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	digest, err := chartSourceDigest(path, d.Get("dependency_update").(bool))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	destination := d.Get("destination").(string)
+	if err := os.MkdirAll(destination, os.ModePerm); err != nil {
+		return diag.FromErr(err)
+	}
+
+	client := action.NewPackage()
+	client.Version = d.Get("version").(string)
+	client.AppVersion = d.Get("app_version").(string)
+	client.Destination = destination
+
+	debug("%s Packaging chart", logID)
+	archive, err := client.Run(path, nil)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := normalizeChartArchive(archive); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(archive)
+
+	if err := d.Set("source_digest", digest); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := d.Set("archive_path", archive); err != nil {
+		return diag.FromErr(err)
+	}
+
+	provenancePath := ""
+	if raw := d.Get("sign").([]interface{}); len(raw) > 0 && raw[0] != nil {
+		debug("%s Signing chart", logID)
+		if err := signChartArchive(archive, raw[0].(map[string]interface{})); err != nil {
+			return diag.FromErr(err)
+		}
+		provenancePath = archive + ".prov"
+	}
+
+	if err := d.Set("provenance_path", provenancePath); err != nil {
+		return diag.FromErr(err)
+	}
+
+	debug("%s Done", logID)
+
+	return resourceChartPackageRead(ctx, d, meta)
+}
+
+func resourceChartPackageRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	archive := d.Id()
+
+	if _, err := os.Stat(archive); os.IsNotExist(err) {
+		debug("chart archive %s is gone, packaging again", archive)
+		d.SetId("")
+		return nil
+	}
+
+	if err := setChartPackageAttributes(d, archive); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+func resourceChartPackageDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	for _, p := range []string{d.Get("archive_path").(string), d.Get("provenance_path").(string)} {
+		if p == "" {
+			continue
+		}
+
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return diag.FromErr(err)
+		}
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// resourceChartPackageDiff packages the chart again when its files change
+func resourceChartPackageDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if !d.NewValueKnown("path") {
+		return nil
+	}
+
+	digest, err := chartSourceDigest(d.Get("path").(string), d.Get("dependency_update").(bool))
+	if err != nil {
+		return err
+	}
+
+	old, _ := d.GetChange("source_digest")
+	if d.Id() != "" && old.(string) != digest {
+		return d.SetNew("source_digest", digest)
+	}
+	return nil
+}
+
+func setChartPackageAttributes(d *schema.ResourceData, archive string) error {
+	c, err := loader.Load(archive)
+	if err != nil {
+		return err
+	}
+
+	digest, err := provenance.DigestFile(archive)
+	if err != nil {
+		return err
+	}
+
+	if err := d.Set("sha256", digest); err != nil {
+		return err
+	}
+
+	return d.Set("metadata", flattenChartPackageMetadata(c.Metadata))
+}
+
+func flattenChartPackageMetadata(md *chart.Metadata) []map[string]interface{} {
+	return []map[string]interface{}{{
+		"name":        md.Name,
+		"version":     md.Version,
+		"app_version": md.AppVersion,
+		"type":        md.Type,
+		"description": md.Description,
+	}}
+}
+
+// chartSourceDigest returns the digest of the files of the chart directory,
+// honouring its .helmignore. The dependencies are left out if they are
+// updated when packaging, as updating them rewrites them.
+func chartSourceDigest(path string, dependencyUpdate bool) (string, error) {
+	c, err := loader.LoadDir(path)
+	if err != nil {
+		return "", err
+	}
+
+	files := make([]*chart.File, 0, len(c.Raw))
+	for _, f := range c.Raw {
+		if dependencyUpdate && (f.Name == "Chart.lock" || strings.HasPrefix(f.Name, "charts/")) {
+			continue
+		}
+		files = append(files, f)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+
+	h := sha256.New()
+	for _, f := range files {
+		fmt.Fprintf(h, "%s\x00%d\x00", f.Name, len(f.Data))
+		h.Write(f.Data)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// chartArchiveTime is the modification time of the files of the chart
+// archives, Helm setting the current time otherwise
+var chartArchiveTime = time.Unix(0, 0)
+
+// normalizeChartArchive rewrites the archive with fixed modification times,
+// for the same chart to always give the same archive and digest
+func normalizeChartArchive(archive string) error {
+	in, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	zr, err := gzip.NewReader(in)
+	if err != nil {
+		return err
+	}
+
+	out, err := ioutil.TempFile(filepath.Dir(archive), filepath.Base(archive)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+
+	zw := gzip.NewWriter(out)
+	zw.Header.Extra = zr.Header.Extra
+	zw.Header.Comment = zr.Header.Comment
+
+	tr := tar.NewReader(zr)
+	tw := tar.NewWriter(zw)
+
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		h.ModTime = chartArchiveTime
+		h.AccessTime = time.Time{}
+		h.ChangeTime = time.Time{}
+
+		if err := tw.WriteHeader(h); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	in.Close()
+
+	if err := os.Chmod(out.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(out.Name(), archive)
+}
+
+func updateChartDependencies(m *Meta, path string) error {
+	man := &downloader.Manager{
+		Out:              ioutil.Discard,
+		ChartPath:        path,
+		SkipUpdate:       false,
+		Getters:          getter.All(m.Settings),
+		RepositoryConfig: m.Settings.RepositoryConfig,
+		RepositoryCache:  m.Settings.RepositoryCache,
+		Debug:            m.Settings.Debug,
+	}
+	return man.Update()
+}
+
+// signChartArchive writes the provenance file of the archive, like
+// `helm package --sign` does, without prompting for the passphrase
+func signChartArchive(archive string, sign map[string]interface{}) error {
+	signer, err := provenance.NewFromKeyring(sign["keyring"].(string), sign["key"].(string))
+	if err != nil {
+		return err
+	}
+
+	passphrase := sign["passphrase"].(string)
+	if err := signer.DecryptKey(func(name string) ([]byte, error) {
+		return []byte(passphrase), nil
+	}); err != nil {
+		return err
+	}
+
+	sig, err := signer.ClearSign(archive)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(archive+".prov", []byte(sig), 0644)
+}
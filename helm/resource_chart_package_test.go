@@ -0,0 +1,160 @@
+package helm
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+
+	"helm.sh/helm/v3/pkg/chart/loader"
+)
+
+func TestAccResourceChartPackage_basic(t *testing.T) {
+	destination := t.TempDir()
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckHelmChartPackageDestroy,
+		Steps: []resource.TestStep{{
+			Config: testAccHelmChartPackageConfig(testResourceName, destination, "9.9.9"),
+			Check: resource.ComposeAggregateTestCheckFunc(
+				resource.TestCheckResourceAttr("helm_chart_package.test", "archive_path", filepath.Join(destination, "test-chart-9.9.9.tgz")),
+				resource.TestCheckResourceAttr("helm_chart_package.test", "metadata.0.name", "test-chart"),
+				resource.TestCheckResourceAttr("helm_chart_package.test", "metadata.0.version", "9.9.9"),
+				resource.TestCheckResourceAttr("helm_chart_package.test", "metadata.0.app_version", "1.19.5"),
+				resource.TestCheckResourceAttrSet("helm_chart_package.test", "sha256"),
+				resource.TestCheckResourceAttr("helm_chart_package.test", "provenance_path", ""),
+			),
+		}, {
+			Config:   testAccHelmChartPackageConfig(testResourceName, destination, "9.9.9"),
+			PlanOnly: true,
+		}},
+	})
+}
+
+func TestResourceChartPackage(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "test-chart")
+	c, err := loader.Load(filepath.Join(testChartsPath, "test-chart"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range c.Raw {
+		p := filepath.Join(src, f.Name)
+		if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, f.Data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	destination := t.TempDir()
+	d := schema.TestResourceDataRaw(t, resourceChartPackage().Schema, map[string]interface{}{
+		"path":        src,
+		"destination": destination,
+		"app_version": "2.0.0",
+	})
+
+	if diags := resourceChartPackageCreate(context.Background(), d, newTestMeta(t)); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	archive := filepath.Join(destination, "test-chart-1.2.3.tgz")
+	if d.Id() != archive || d.Get("archive_path") != archive {
+		t.Fatalf("unexpected archive path %q", d.Id())
+	}
+
+	if d.Get("metadata.0.app_version") != "2.0.0" {
+		t.Errorf("expected the app version to be overridden, got %v", d.Get("metadata.0.app_version"))
+	}
+
+	if len(d.Get("sha256").(string)) != 64 {
+		t.Errorf("unexpected digest %q", d.Get("sha256"))
+	}
+
+	f, err := os.Open(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(zr)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !h.ModTime.Equal(chartArchiveTime) {
+			t.Errorf("expected %s to have a fixed modification time, got %s", h.Name, h.ModTime)
+		}
+	}
+
+	// packaging the same chart again gives the same archive
+	sum := d.Get("sha256").(string)
+	if diags := resourceChartPackageCreate(context.Background(), d, newTestMeta(t)); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if d.Get("sha256") != sum {
+		t.Errorf("expected the digest of the archive to be the same, got %v and %v", sum, d.Get("sha256"))
+	}
+
+	digest := d.Get("source_digest").(string)
+	if err := ioutil.WriteFile(filepath.Join(src, "templates", "extra.yaml"), []byte("# extra"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := chartSourceDigest(src, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed == digest {
+		t.Error("expected the source digest to change with the chart files")
+	}
+
+	if diags := resourceChartPackageDelete(context.Background(), d, nil); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	if _, err := os.Stat(archive); !os.IsNotExist(err) {
+		t.Errorf("expected the archive to be removed, got %v", err)
+	}
+}
+
+func testAccCheckHelmChartPackageDestroy(s *terraform.State) error {
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "helm_chart_package" {
+			continue
+		}
+
+		if _, err := os.Stat(rs.Primary.ID); !os.IsNotExist(err) {
+			return fmt.Errorf("chart archive %s still exists", rs.Primary.ID)
+		}
+	}
+	return nil
+}
+
+func testAccHelmChartPackageConfig(resource, destination, version string) string {
+	return fmt.Sprintf(`
+		resource "helm_chart_package" "%s" {
+			path        = "%s/test-chart"
+			destination = %q
+			version     = %q
+		}
+	`, resource, testChartsPath, destination, version)
+}
@@ -0,0 +1,380 @@
+package helm
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pkg/errors"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/provenance"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+func resourceChartPush() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceChartPushCreate,
+		ReadContext:   resourceChartPushRead,
+		DeleteContext: resourceChartPushDelete,
+		CustomizeDiff: resourceChartPushDiff,
+		Schema: map[string]*schema.Schema{
+			"chart": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Path of the chart archive or directory to push.",
+			},
+			"repository": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Where to push the chart to. Either an `oci://` registry reference, the URL of an HTTP repository accepting uploads, or the name of a configured repository.",
+			},
+			"repository_key_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "The repositories cert key file",
+			},
+			"repository_cert_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "The repositories cert file",
+			},
+			"repository_ca_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "The Repositories CA File",
+			},
+			"repository_username": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Username for HTTP basic authentication",
+			},
+			"repository_password": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Sensitive:   true,
+				Description: "Password for HTTP basic authentication",
+			},
+			"upload_path": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Default:     "/api/charts",
+				Description: "Path of the upload endpoint of HTTP repositories, relative to the repository URL.",
+			},
+			"source_digest": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				ForceNew:    true,
+				Description: "The SHA256 digest of the chart, used to push the chart again when it changes.",
+			},
+			"name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The name of the pushed chart.",
+			},
+			"version": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The version of the pushed chart.",
+			},
+			"reference": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The reference of the pushed chart in the registry, or the URL it was uploaded to.",
+			},
+			"digest": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The digest of the manifest pushed to the registry, or of the uploaded archive.",
+			},
+		},
+	}
+}
+
+func resourceChartPushCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	logID := fmt.Sprintf("[resourceChartPushCreate: %s]", d.Get("chart").(string))
+	debug("%s Started", logID)
+
+	m := meta.(*Meta)
+	path := d.Get("chart").(string)
+
+	c, archive, err := loadChartArchive(path)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	digest, err := chartPushSourceDigest(path)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	repository := d.Get("repository").(string)
+
+	var reference, pushedDigest string
+	if isOCIChart(repository) {
+		debug("%s Pushing chart to registry", logID)
+		reference, pushedDigest, err = pushOCIChart(m, repository, c, archive)
+	} else {
+		debug("%s Uploading chart to repository", logID)
+		reference, pushedDigest, err = uploadChart(m, d, c, archive)
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(reference)
+
+	attributes := map[string]interface{}{
+		"source_digest": digest,
+		"name":          c.Metadata.Name,
+		"version":       c.Metadata.Version,
+		"reference":     reference,
+		"digest":        pushedDigest,
+	}
+
+	for k, v := range attributes {
+		if err := d.Set(k, v); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	debug("%s Done", logID)
+
+	return nil
+}
+
+func resourceChartPushRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	// Registries and repositories are not queried, the pushed chart is
+	// considered immutable.
+	return nil
+}
+
+func resourceChartPushDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	// Pushed charts are left in place, as other consumers may depend on them.
+	d.SetId("")
+	return nil
+}
+
+// resourceChartPushDiff pushes the chart again when it changes
+func resourceChartPushDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || !d.NewValueKnown("chart") {
+		return nil
+	}
+
+	digest, err := chartPushSourceDigest(d.Get("chart").(string))
+	if err != nil {
+		return err
+	}
+
+	if old, _ := d.GetChange("source_digest"); old.(string) != digest {
+		return d.SetNew("source_digest", digest)
+	}
+	return nil
+}
+
+// chartPushSourceDigest returns the digest of the files of a chart directory
+// or of a chart archive
+func chartPushSourceDigest(path string) (string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	if fi.IsDir() {
+		return chartSourceDigest(path, false)
+	}
+	return provenance.DigestFile(path)
+}
+
+// loadChartArchive loads the chart at path and returns its archive, packaging
+// it first if path is a directory
+func loadChartArchive(path string) (*chart.Chart, []byte, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !fi.IsDir() {
+		archive, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		c, err := loader.LoadArchive(bytes.NewReader(archive))
+		if err != nil {
+			return nil, nil, err
+		}
+		return c, archive, nil
+	}
+
+	c, err := loader.LoadDir(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dir, err := ioutil.TempDir("", "helm-push-")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	filename, err := chartutil.Save(c, dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	archive, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, archive, nil
+}
+
+// pushOCIChart pushes the chart to the oci:// repository, tagged with the
+// chart version, and returns the reference and the digest of the manifest
+func pushOCIChart(m *Meta, repository string, c *chart.Chart, archive []byte) (string, string, error) {
+	ref := fmt.Sprintf("%s/%s:%s", strings.TrimSuffix(strings.TrimPrefix(repository, ociScheme), "/"), c.Metadata.Name, c.Metadata.Version)
+
+	metadata, err := json.Marshal(c.Metadata)
+	if err != nil {
+		return "", "", err
+	}
+
+	client, err := newRegistryClient(m, strings.SplitN(ref, "/", 2)[0])
+	if err != nil {
+		return "", "", err
+	}
+
+	manifest, err := client.PushChart(ref, archive, metadata)
+	if err != nil {
+		return "", "", err
+	}
+
+	return ref, manifest.Digest.String(), nil
+}
+
+// uploadChart posts the archive to the upload endpoint of an HTTP repository,
+// like ChartMuseum does, and returns the upload URL and the digest of the
+// archive
+func uploadChart(m *Meta, d resourceGetter, c *chart.Chart, archive []byte) (string, string, error) {
+	e, err := chartPushRepositoryEntry(m, d)
+	if err != nil {
+		return "", "", err
+	}
+
+	client, err := repositoryHTTPClient(e)
+	if err != nil {
+		return "", "", err
+	}
+
+	u := strings.TrimSuffix(e.URL, "/") + "/" + strings.TrimPrefix(d.Get("upload_path").(string), "/")
+
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(archive))
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	if e.Username != "" || e.Password != "" {
+		req.SetBasicAuth(e.Username, e.Password)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to upload chart %s-%s", c.Metadata.Name, c.Metadata.Version)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return "", "", errors.Errorf("failed to upload chart %s-%s to %s: %s %s", c.Metadata.Name, c.Metadata.Version, u, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	sum := sha256.Sum256(archive)
+	return u, "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// chartPushRepositoryEntry returns the repository to upload to, looking it up
+// in the configured repositories unless it is a URL
+func chartPushRepositoryEntry(m *Meta, d resourceGetter) (*repo.Entry, error) {
+	repository := d.Get("repository").(string)
+
+	e := &repo.Entry{URL: repository}
+	if _, err := url.ParseRequestURI(repository); err != nil {
+		f, err := loadRepositoryFile(m)
+		if err != nil {
+			return nil, err
+		}
+
+		if e = f.Get(repository); e == nil {
+			return nil, errors.Errorf("repository %q is neither a URL nor a configured repository", repository)
+		}
+	}
+
+	overrides := map[string]*string{
+		"repository_username":  &e.Username,
+		"repository_password":  &e.Password,
+		"repository_ca_file":   &e.CAFile,
+		"repository_cert_file": &e.CertFile,
+		"repository_key_file":  &e.KeyFile,
+	}
+
+	for k, v := range overrides {
+		if s := d.Get(k).(string); s != "" {
+			*v = s
+		}
+	}
+
+	return e, nil
+}
+
+func repositoryHTTPClient(e *repo.Entry) (*http.Client, error) {
+	config := &tls.Config{InsecureSkipVerify: e.InsecureSkipTLSverify}
+
+	if e.CertFile != "" && e.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(e.CertFile, e.KeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "can't load client certificate")
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if e.CAFile != "" {
+		ca, err := ioutil.ReadFile(e.CAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "can't read CA file")
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.Errorf("failed to append certificates from %s", e.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+
+	return &http.Client{Transport: transport}, nil
+}
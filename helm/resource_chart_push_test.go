@@ -0,0 +1,139 @@
+package helm
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+)
+
+func TestAccResourceChartPush_oci(t *testing.T) {
+	r := newTestRegistry(t)
+	repository := fmt.Sprintf("oci://%s/charts", r.Host())
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{{
+			Config: testAccHelmChartPushConfig(testResourceName, repository),
+			Check: resource.ComposeAggregateTestCheckFunc(
+				resource.TestCheckResourceAttr("helm_chart_push.test", "reference", fmt.Sprintf("%s/charts/test-chart:1.2.3", r.Host())),
+				resource.TestCheckResourceAttr("helm_chart_push.test", "name", "test-chart"),
+				resource.TestCheckResourceAttr("helm_chart_push.test", "version", "1.2.3"),
+				resource.TestCheckResourceAttrSet("helm_chart_push.test", "digest"),
+			),
+		}, {
+			Config:   testAccHelmChartPushConfig(testResourceName, repository),
+			PlanOnly: true,
+		}},
+	})
+}
+
+func TestResourceChartPushOCI(t *testing.T) {
+	r := newTestRegistry(t)
+	m := newTestMeta(t)
+	m.insecureRegistries[r.Host()] = true
+
+	d := schema.TestResourceDataRaw(t, resourceChartPush().Schema, map[string]interface{}{
+		"chart":      filepath.Join(testChartsPath, "test-chart"),
+		"repository": fmt.Sprintf("oci://%s/charts/", r.Host()),
+	})
+
+	if diags := resourceChartPushCreate(context.Background(), d, m); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	ref := fmt.Sprintf("%s/charts/test-chart:1.2.3", r.Host())
+	if d.Id() != ref {
+		t.Fatalf("unexpected reference %q", d.Id())
+	}
+
+	if !strings.HasPrefix(d.Get("digest").(string), "sha256:") {
+		t.Errorf("unexpected digest %q", d.Get("digest"))
+	}
+
+	// The pushed chart can be pulled back
+	path, err := locateChart(m, fmt.Sprintf("oci://%s/charts/test-chart", r.Host()), &action.ChartPathOptions{Version: "1.2.3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := loader.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Metadata.Name != "test-chart" || c.Metadata.Version != "1.2.3" {
+		t.Fatalf("unexpected chart %s-%s", c.Metadata.Name, c.Metadata.Version)
+	}
+}
+
+func TestResourceChartPushHTTP(t *testing.T) {
+	var uploaded []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		username, password, _ := req.BasicAuth()
+		if req.Method != http.MethodPost || req.URL.Path != "/api/charts" || username != "user" || password != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		uploaded, _ = ioutil.ReadAll(req.Body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	d := schema.TestResourceDataRaw(t, resourceChartPush().Schema, map[string]interface{}{
+		"chart":               filepath.Join(testChartsPath, "test-chart"),
+		"repository":          server.URL,
+		"repository_username": "user",
+		"repository_password": "secret",
+	})
+
+	if diags := resourceChartPushCreate(context.Background(), d, newTestMeta(t)); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	if d.Id() != server.URL+"/api/charts" {
+		t.Fatalf("unexpected reference %q", d.Id())
+	}
+
+	c, err := loader.LoadArchive(strings.NewReader(string(uploaded)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Metadata.Name != "test-chart" {
+		t.Fatalf("unexpected chart %q", c.Metadata.Name)
+	}
+
+	d.Set("repository_password", "wrong")
+	if diags := resourceChartPushCreate(context.Background(), d, newTestMeta(t)); !diags.HasError() {
+		t.Fatal("expected an error uploading with wrong credentials")
+	}
+}
+
+func testAccHelmChartPushConfig(resource, repository string) string {
+	return fmt.Sprintf(`
+		provider "helm" {
+			registry {
+				host     = %q
+				insecure = true
+			}
+		}
+
+		resource "helm_chart_push" "%s" {
+			chart      = "%s/test-chart"
+			repository = %q
+		}
+	`, strings.TrimPrefix(strings.SplitN(repository, "/charts", 2)[0], "oci://"), resource, testChartsPath, repository)
+}
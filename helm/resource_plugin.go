@@ -0,0 +1,393 @@
+package helm
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pkg/errors"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/plugin"
+)
+
+func resourcePlugin() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourcePluginCreate,
+		ReadContext:   resourcePluginRead,
+		DeleteContext: resourcePluginDelete,
+		Schema: map[string]*schema.Schema{
+			"source": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Where to install the plugin from. Either a local directory, a local .tgz archive or the URL of a .tgz archive.",
+			},
+			"name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The name of the plugin.",
+			},
+			"version": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The version of the plugin.",
+			},
+			"usage": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The single-line usage text of the plugin.",
+			},
+			"description": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The description of the plugin.",
+			},
+			"path": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The directory the plugin is installed in.",
+			},
+			"downloaders": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The downloaders of the plugin, fetching charts for the protocols they support.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"command": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The command performing the download.",
+						},
+						"protocols": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "The URL schemes handled by the downloader.",
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func resourcePluginCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	source := d.Get("source").(string)
+	logID := fmt.Sprintf("[resourcePluginCreate: %s]", source)
+	debug("%s Started", logID)
+
+	m := meta.(*Meta)
+
+	debug("%s Installing plugin", logID)
+	p, err := installPlugin(m, source)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(p.Metadata.Name)
+
+	debug("%s Done", logID)
+
+	return resourcePluginRead(ctx, d, meta)
+}
+
+func resourcePluginRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	m := meta.(*Meta)
+
+	dir := filepath.Join(m.Settings.PluginsDirectory, d.Id())
+	if _, err := os.Stat(filepath.Join(dir, plugin.PluginFileName)); os.IsNotExist(err) {
+		debug("plugin %s is gone, installing again", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	p, err := plugin.LoadDir(dir)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	attributes := map[string]interface{}{
+		"name":        p.Metadata.Name,
+		"version":     p.Metadata.Version,
+		"usage":       p.Metadata.Usage,
+		"description": p.Metadata.Description,
+		"path":        p.Dir,
+		"downloaders": flattenPluginDownloaders(p.Metadata.Downloaders),
+	}
+
+	for k, v := range attributes {
+		if err := d.Set(k, v); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	return nil
+}
+
+func resourcePluginDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	m := meta.(*Meta)
+
+	dir := filepath.Join(m.Settings.PluginsDirectory, d.Id())
+	p, err := plugin.LoadDir(dir)
+	if os.IsNotExist(errors.Cause(err)) {
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := runPluginHook(m, p, plugin.Delete); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// installPlugin installs the plugin found at source into the plugins
+// directory, like `helm plugin install` does. Unlike Helm, local directories
+// are copied rather than linked, so the plugin outlives its source.
+func installPlugin(m *Meta, source string) (*plugin.Plugin, error) {
+	staging, err := ioutil.TempDir("", "helm-plugin-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	src, err := stagePlugin(m, source, staging)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := plugin.LoadDir(src)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(m.Settings.PluginsDirectory, p.Metadata.Name)
+	if _, err := os.Stat(dir); err == nil {
+		return nil, errors.Errorf("plugin %q already exists in %s", p.Metadata.Name, m.Settings.PluginsDirectory)
+	}
+
+	if err := copyDir(src, dir); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	if p, err = plugin.LoadDir(dir); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	if err := runPluginHook(m, p, plugin.Install); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// stagePlugin makes the plugin at source available in the staging directory
+// and returns the directory holding its plugin.yaml
+func stagePlugin(m *Meta, source, staging string) (string, error) {
+	if u, err := url.Parse(source); err == nil && u.Scheme != "" && len(u.Scheme) > 1 {
+		g, err := getter.All(m.Settings).ByScheme(u.Scheme)
+		if err != nil {
+			return "", errors.Wrapf(err, "cannot download plugin from %s", source)
+		}
+
+		data, err := g.Get(source)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to download plugin from %s", source)
+		}
+
+		if err := extractPluginArchive(data, staging); err != nil {
+			return "", err
+		}
+		return findPluginDir(staging)
+	}
+
+	fi, err := os.Stat(source)
+	if err != nil {
+		return "", err
+	}
+
+	if fi.IsDir() {
+		return source, nil
+	}
+
+	data, err := ioutil.ReadFile(source)
+	if err != nil {
+		return "", err
+	}
+
+	if err := extractPluginArchive(bytes.NewBuffer(data), staging); err != nil {
+		return "", err
+	}
+	return findPluginDir(staging)
+}
+
+// findPluginDir returns the directory holding the plugin.yaml of an extracted
+// archive, either its root or its single top-level directory
+func findPluginDir(dir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, plugin.PluginFileName)); err == nil {
+		return dir, nil
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, "*", plugin.PluginFileName))
+	if err != nil {
+		return "", err
+	}
+
+	if len(matches) != 1 {
+		return "", errors.Errorf("archive does not contain a %s", plugin.PluginFileName)
+	}
+	return filepath.Dir(matches[0]), nil
+}
+
+// extractPluginArchive extracts a gzipped tarball into dir, refusing entries
+// escaping it
+func extractPluginArchive(r io.Reader, dir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return errors.Wrap(err, "plugin archive is not a gzipped tarball")
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(dir, header.Name)
+		if path != dir && !strings.HasPrefix(path, dir+string(os.PathSeparator)) {
+			return errors.Errorf("illegal file path in plugin archive: %s", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			if err := writePluginFile(path, tr, os.FileMode(header.Mode)); err != nil {
+				return err
+			}
+		default:
+			return errors.Errorf("unknown type %b in plugin archive: %s", header.Typeflag, header.Name)
+		}
+	}
+}
+
+// copyDir copies the files of src into dst, keeping their modes
+func copyDir(src, dst string) error {
+	return filepath.Walk(src, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if fi.IsDir() {
+			return os.MkdirAll(target, fi.Mode().Perm()|0700)
+		}
+
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		return writePluginFile(target, f, fi.Mode())
+	})
+}
+
+func writePluginFile(path string, r io.Reader, mode os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, r)
+	return err
+}
+
+// runPluginHook runs the command of the plugin for the event, like Helm does
+// after installing or removing a plugin
+func runPluginHook(m *Meta, p *plugin.Plugin, event string) error {
+	hook := p.Metadata.Hooks[event]
+	if hook == "" {
+		return nil
+	}
+
+	debug("running %s hook of plugin %s: %s", event, p.Metadata.Name, hook)
+	cmd := exec.Command("sh", "-c", hook)
+	cmd.Env = pluginEnv(m, p)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "%s hook of plugin %q failed: %s", event, p.Metadata.Name, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// pluginEnv returns the environment of the plugin commands, the one
+// plugin.SetupPluginEnv sets, without changing the environment of the
+// provider that other resources may be using meanwhile
+func pluginEnv(m *Meta, p *plugin.Plugin) []string {
+	vars := m.Settings.EnvVars()
+	vars["HELM_PLUGIN_NAME"] = p.Metadata.Name
+	vars["HELM_PLUGIN_DIR"] = p.Dir
+
+	env := os.Environ()
+	for _, k := range sortedKeys(vars) {
+		env = append(env, k+"="+vars[k])
+	}
+	return env
+}
+
+func flattenPluginDownloaders(downloaders []plugin.Downloaders) []map[string]interface{} {
+	result := []map[string]interface{}{}
+
+	for _, dl := range downloaders {
+		result = append(result, map[string]interface{}{
+			"command":   dl.Command,
+			"protocols": dl.Protocols,
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,177 @@
+package helm
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const testPluginPath = "./testdata/plugins/test-plugin"
+
+func TestAccResourcePlugin_basic(t *testing.T) {
+	dir := t.TempDir()
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{{
+			Config: testAccHelmPluginConfig(testResourceName, dir, testPluginPath),
+			Check: resource.ComposeAggregateTestCheckFunc(
+				resource.TestCheckResourceAttr("helm_plugin.test", "name", "test-plugin"),
+				resource.TestCheckResourceAttr("helm_plugin.test", "version", "0.1.0"),
+				resource.TestCheckResourceAttr("helm_plugin.test", "path", filepath.Join(dir, "test-plugin")),
+				resource.TestCheckResourceAttr("helm_plugin.test", "downloaders.0.protocols.#", "2"),
+			),
+		}},
+	})
+}
+
+func testAccHelmPluginConfig(resource, pluginsPath, source string) string {
+	return fmt.Sprintf(`
+		provider "helm" {
+			plugins_path = %q
+		}
+
+		resource "helm_plugin" "%s" {
+			source = %q
+		}
+	`, pluginsPath, resource, source)
+}
+
+func TestResourcePlugin(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "test-plugin.tgz")
+	writeTestPluginArchive(t, archive)
+
+	server := httptest.NewServer(http.FileServer(http.Dir(filepath.Dir(archive))))
+	defer server.Close()
+
+	sources := map[string]string{
+		"directory": testPluginPath,
+		"archive":   archive,
+		"url":       server.URL + "/test-plugin.tgz",
+	}
+
+	for name, source := range sources {
+		t.Run(name, func(t *testing.T) {
+			m := newTestMeta(t)
+
+			d := schema.TestResourceDataRaw(t, resourcePlugin().Schema, map[string]interface{}{
+				"source": source,
+			})
+
+			if diags := resourcePluginCreate(context.Background(), d, m); diags.HasError() {
+				t.Fatal(diags)
+			}
+
+			dir := filepath.Join(m.Settings.PluginsDirectory, "test-plugin")
+			if d.Get("path") != dir {
+				t.Errorf("expected plugin to be installed in %s, got %s", dir, d.Get("path"))
+			}
+
+			if d.Get("version") != "0.1.0" {
+				t.Errorf("unexpected version %q", d.Get("version"))
+			}
+
+			if d.Get("downloaders.0.protocols.1") != "tests" {
+				t.Errorf("unexpected downloaders %v", d.Get("downloaders"))
+			}
+
+			if _, err := os.Stat(filepath.Join(dir, "installed")); err != nil {
+				t.Errorf("expected the install hook to run: %s", err)
+			}
+
+			if v, ok := os.LookupEnv("HELM_PLUGIN_DIR"); ok {
+				t.Errorf("expected the environment of the provider to be left as it is, got HELM_PLUGIN_DIR=%s", v)
+			}
+
+			if fi, err := os.Stat(filepath.Join(dir, "test-plugin.sh")); err != nil || fi.Mode().Perm()&0100 == 0 {
+				t.Errorf("expected the plugin command to be executable: %v", err)
+			}
+
+			// Installing the plugin twice fails
+			if diags := resourcePluginCreate(context.Background(), d, m); !diags.HasError() {
+				t.Error("expected an error installing an existing plugin")
+			}
+
+			if diags := resourcePluginDelete(context.Background(), d, m); diags.HasError() {
+				t.Fatal(diags)
+			}
+
+			if _, err := os.Stat(dir); !os.IsNotExist(err) {
+				t.Errorf("expected plugin to be removed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestResourcePlugin_invalid(t *testing.T) {
+	m := newTestMeta(t)
+
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "plugin.yaml"), []byte("name: not/valid\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	d := schema.TestResourceDataRaw(t, resourcePlugin().Schema, map[string]interface{}{
+		"source": dir,
+	})
+
+	if diags := resourcePluginCreate(context.Background(), d, m); !diags.HasError() {
+		t.Fatal("expected an error for an invalid plugin name")
+	}
+
+	if _, err := os.Stat(m.Settings.PluginsDirectory); !os.IsNotExist(err) {
+		t.Errorf("expected nothing to be installed, got %v", err)
+	}
+}
+
+// writeTestPluginArchive archives the test plugin under a top-level directory,
+// like plugin releases do
+func writeTestPluginArchive(t *testing.T, path string) {
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	defer gz.Close()
+
+	tw := tar.NewWriter(gz)
+	defer tw.Close()
+
+	files, err := ioutil.ReadDir(testPluginPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, fi := range files {
+		data, err := ioutil.ReadFile(filepath.Join(testPluginPath, fi.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		header := &tar.Header{
+			Name:     "test-plugin/" + fi.Name(),
+			Mode:     int64(fi.Mode().Perm()),
+			Size:     int64(len(data)),
+			Typeflag: tar.TypeReg,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
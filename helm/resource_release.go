@@ -2,11 +2,19 @@ package helm
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"time"
 
@@ -14,6 +22,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
@@ -21,6 +30,7 @@ import (
 	"helm.sh/helm/v3/pkg/getter"
 	"helm.sh/helm/v3/pkg/postrender"
 	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/repo"
 	"helm.sh/helm/v3/pkg/strvals"
 	"sigs.k8s.io/yaml"
 )
@@ -103,7 +113,7 @@ func resourceRelease() *schema.Resource {
 			"chart": {
 				Type:        schema.TypeString,
 				Required:    true,
-				Description: "Chart name to be installed. A path may be used.",
+				Description: "Chart name to be installed. A path, a URL or an `oci://` reference may be used.",
 			},
 			"version": {
 				Type:        schema.TypeString,
@@ -111,6 +121,11 @@ func resourceRelease() *schema.Resource {
 				Computed:    true,
 				Description: "Specify the exact chart version to install. If this is not specified, the latest version is installed.",
 			},
+			"latest_version": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The latest version of the chart published in the repository. Development versions are only considered if `devel` is set.",
+			},
 			"devel": {
 				Type:        schema.TypeBool,
 				Optional:    true,
@@ -126,6 +141,48 @@ func resourceRelease() *schema.Resource {
 				Description: "List of values in raw yaml format to pass to helm.",
 				Elem:        &schema.Schema{Type: schema.TypeString},
 			},
+			"values_files": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "List of paths, or glob patterns, of values files in yaml format to merge before the values.",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"values_from": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "ConfigMaps and Secrets to read values from, merged in order before the other values.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"kind": {
+							Type:     schema.TypeString,
+							Required: true,
+							ValidateFunc: validation.StringInSlice([]string{
+								"ConfigMap", "Secret",
+							}, false),
+						},
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"namespace": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Namespace of the object. Defaults to the namespace of the release.",
+						},
+						"key": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     "values.yaml",
+							Description: "Key of the data holding the values.",
+						},
+						"target_path": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Path of the value set to the data. The data is merged as values in yaml format if not set.",
+						},
+					},
+				},
+			},
 			"set": {
 				Type:        schema.TypeSet,
 				Optional:    true,
@@ -146,7 +203,7 @@ func resourceRelease() *schema.Resource {
 							// TODO: use ValidateDiagFunc once an SDK v2 version of StringInSlice exists.
 							// https://github.com/hashicorp/terraform-plugin-sdk/issues/534
 							ValidateFunc: validation.StringInSlice([]string{
-								"auto", "string",
+								"auto", "string", "json", "literal",
 							}, false),
 						},
 					},
@@ -171,12 +228,81 @@ func resourceRelease() *schema.Resource {
 							Type:     schema.TypeString,
 							Optional: true,
 							ValidateFunc: validation.StringInSlice([]string{
-								"auto", "string",
+								"auto", "string", "json", "literal",
 							}, false),
 						},
 					},
 				},
 			},
+			"set_list": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Custom list values to be merged with the values.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"value": {
+							Type:     schema.TypeList,
+							Required: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+			"set_file": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "Custom values read from files to be merged with the values. The contents of the files are cloaked.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"path": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+					},
+				},
+			},
+			"set_from_env": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "Custom sensitive values read from environment variables when the values are computed. They are not stored in the state.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"env": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+					},
+				},
+			},
+			"set_from_file": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "Custom sensitive values read from files when the values are computed. They are not stored in the state.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"path": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+					},
+				},
+			},
 			"namespace": {
 				Type:        schema.TypeString,
 				Optional:    true,
@@ -342,6 +468,12 @@ func resourceRelease() *schema.Resource {
 				Default:     defaultAttributes["lint"],
 				Description: "Run helm lint when planning",
 			},
+			"rollback_to_revision": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "When changed, roll the release back to the given revision instead of upgrading it. It cannot be changed along with other attributes, except the version of the chart of the revision.",
+			},
 			"manifest": {
 				Type:        schema.TypeString,
 				Description: "The rendered manifest as JSON.",
@@ -391,6 +523,25 @@ func resourceRelease() *schema.Resource {
 					},
 				},
 			},
+			"file_hashes": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Description: "SHA-256 of the contents of the values_files and set_file files, by path. Changing the contents of a file upgrades the release.",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"set_from_hashes": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Description: "Salted scrypt hashes of the set_from_env and set_from_file values, by name. Changing a value upgrades the release.",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"values_from_hashes": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Description: "SHA-256 of the data read from the values_from ConfigMaps and Secrets, by kind/namespace/name/key. Changing the data upgrades the release.",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"history": releaseHistorySchema(),
 		},
 	}
 }
@@ -423,14 +574,30 @@ func resourceReleaseRead(ctx context.Context, d *schema.ResourceData, meta inter
 		return diag.FromErr(err)
 	}
 
-	err = setReleaseAttributes(d, r, m)
+	from, diags := getStateValuesFrom(ctx, d, m)
+
+	err = setReleaseAttributes(d, r, m, from)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
+	if err := setReleaseHistory(d, m, c); err != nil {
+		return diag.FromErr(err)
+	}
+
+	// The release can still be refreshed, and destroyed, while its repository
+	// cannot be read
+	if err := setLatestVersion(d, m); err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Could not refresh latest_version",
+			Detail:   err.Error(),
+		})
+	}
+
 	debug("%s Done", logID)
 
-	return nil
+	return diags
 }
 
 func checkChartDependencies(d resourceGetter, c *chart.Chart, path string, m *Meta) (bool, error) {
@@ -498,11 +665,20 @@ func resourceReleaseCreate(ctx context.Context, d *schema.ResourceData, meta int
 	}
 
 	debug("%s Preparing for installation", logID)
-	values, err := getValues(d)
+	from, err := getValuesFrom(ctx, d, m)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	values, err := getReleaseValues(d, from)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
+	if diags := validateReleaseValues(d, c, from, values); diags.HasError() {
+		return diags
+	}
+
 	err = isChartInstallable(c)
 	if err != nil {
 		return diag.FromErr(err)
@@ -562,7 +738,15 @@ func resourceReleaseCreate(ctx context.Context, d *schema.ResourceData, meta int
 
 		debug("%s Release was created but returned an error", logID)
 
-		if err := setReleaseAttributes(d, rel, m); err != nil {
+		if err := setReleaseHashes(d, from); err != nil {
+			return diag.FromErr(err)
+		}
+
+		if err := setReleaseAttributes(d, rel, m, from); err != nil {
+			return diag.FromErr(err)
+		}
+
+		if err := setReleaseHistory(d, m, actionConfig); err != nil {
 			return diag.FromErr(err)
 		}
 
@@ -579,10 +763,18 @@ func resourceReleaseCreate(ctx context.Context, d *schema.ResourceData, meta int
 
 	}
 
-	err = setReleaseAttributes(d, rel, m)
+	if err := setReleaseHashes(d, from); err != nil {
+		return diag.FromErr(err)
+	}
+
+	err = setReleaseAttributes(d, rel, m, from)
 	if err != nil {
 		return diag.FromErr(err)
 	}
+
+	if err := setReleaseHistory(d, m, actionConfig); err != nil {
+		return diag.FromErr(err)
+	}
 	return nil
 }
 
@@ -594,6 +786,10 @@ func resourceReleaseUpdate(ctx context.Context, d *schema.ResourceData, meta int
 		return diag.FromErr(err)
 	}
 
+	if isRollback(d) {
+		return resourceReleaseRollback(ctx, d, m, actionConfig)
+	}
+
 	cpo, chartName, err := chartPathOptions(d, m)
 	if err != nil {
 		return diag.FromErr(err)
@@ -646,21 +842,196 @@ func resourceReleaseUpdate(ctx context.Context, d *schema.ResourceData, meta int
 		client.PostRenderer = pr
 	}
 
-	values, err := getValues(d)
+	from, err := getValuesFrom(ctx, d, m)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	values, err := getReleaseValues(d, from)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
+	if diags := validateReleaseValues(d, c, from, values); diags.HasError() {
+		return diags
+	}
+
 	name := d.Get("name").(string)
 	r, err := client.Run(name, c, values)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	err = setReleaseAttributes(d, r, m)
+	if err := setReleaseHashes(d, from); err != nil {
+		return diag.FromErr(err)
+	}
+
+	err = setReleaseAttributes(d, r, m, from)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := setReleaseHistory(d, m, actionConfig); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// isRollback returns true if the existing release has to be rolled back to the
+// revision set in rollback_to_revision instead of being upgraded
+func isRollback(d resourceChangeGetter) bool {
+	return d.Id() != "" && d.HasChange("rollback_to_revision") && d.Get("rollback_to_revision").(int) > 0
+}
+
+// rollbackSettings are the attributes used by a rollback, that may change
+// along with rollback_to_revision
+var rollbackSettings = map[string]bool{
+	"rollback_to_revision": true,
+	"wait":                 true,
+	"wait_for_jobs":        true,
+	"timeout":              true,
+	"force_update":         true,
+	"recreate_pods":        true,
+	"cleanup_on_fail":      true,
+	"max_history":          true,
+	"disable_webhooks":     true,
+}
+
+// checkRollback rejects a rollback planned along with other changes, which
+// would be dropped, and a version differing from the one of the target
+// revision, which would upgrade the release back on the next apply
+func checkRollback(d *schema.ResourceDiff, m *Meta) error {
+	var changed []string
+	for key, s := range resourceRelease().Schema {
+		if rollbackSettings[key] || key == "version" {
+			continue
+		}
+		// The hashes of the values read from files and the cluster tell if
+		// these values changed
+		if s.Computed && !s.Optional && !strings.HasSuffix(key, "_hashes") {
+			continue
+		}
+		if d.HasChange(key) {
+			changed = append(changed, key)
+		}
+	}
+
+	if len(changed) > 0 {
+		sort.Strings(changed)
+		return fmt.Errorf("rollback_to_revision cannot be changed along with %s, apply the rollback first", strings.Join(changed, ", "))
+	}
+
+	name := d.Get("name").(string)
+	revision := d.Get("rollback_to_revision").(int)
+
+	actionConfig, err := m.GetHelmConfiguration(d.Get("namespace").(string))
+	if err != nil {
+		return err
+	}
+
+	r, err := actionConfig.Releases.Get(name, revision)
+	if err != nil {
+		return fmt.Errorf("could not get revision %d of release %q to roll back to: %s", revision, name, err)
+	}
+
+	if version := d.Get("version").(string); version != r.Chart.Metadata.Version {
+		return fmt.Errorf("revision %d of release %q has version %s of the chart, set version to %q to roll back to it", revision, name, r.Chart.Metadata.Version, r.Chart.Metadata.Version)
+	}
+
+	return nil
+}
+
+// rollbackApplied tells if the release has been rolled back by the last apply
+// and rollback_to_revision is kept as it is
+func rollbackApplied(d *schema.ResourceDiff) bool {
+	return d.Id() != "" && !d.HasChange("rollback_to_revision") && d.Get("rollback_to_revision").(int) > 0
+}
+
+// rolledBackValuesDiffer tells if the values of the configuration differ from
+// the ones of the release, kept cloaked in metadata, the same values being
+// cloaked to compare them
+func rolledBackValuesDiffer(d *schema.ResourceDiff, from *valuesFrom) (bool, error) {
+	values, err := getReleaseValues(d, from)
+	if err != nil {
+		return false, err
+	}
+
+	filePaths, err := getValuesFilesPaths(d)
+	if err != nil {
+		return false, err
+	}
+
+	cloakSetValues(values, d)
+	cloakSecretValues(values, from.secretPaths)
+	cloakSecretValues(values, filePaths)
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		return false, err
+	}
+
+	var configured, deployed interface{}
+	if err := json.Unmarshal(data, &configured); err != nil {
+		return false, err
+	}
+
+	old, _ := d.GetChange("metadata.0.values")
+	if err := json.Unmarshal([]byte(old.(string)), &deployed); err != nil {
+		// the values of the release are not known
+		return false, nil
+	}
+	if deployed == nil {
+		deployed = map[string]interface{}{}
+	}
+
+	return !reflect.DeepEqual(configured, deployed), nil
+}
+
+func resourceReleaseRollback(ctx context.Context, d *schema.ResourceData, m *Meta, actionConfig *action.Configuration) diag.Diagnostics {
+	name := d.Get("name").(string)
+	revision := d.Get("rollback_to_revision").(int)
+
+	logID := fmt.Sprintf("[resourceReleaseRollback: %s]", name)
+	debug("%s Rolling back to revision %d", logID, revision)
+
+	client := action.NewRollback(actionConfig)
+	client.Version = revision
+	client.Timeout = time.Duration(d.Get("timeout").(int)) * time.Second
+	client.Wait = d.Get("wait").(bool)
+	client.WaitForJobs = d.Get("wait_for_jobs").(bool)
+	client.DisableHooks = d.Get("disable_webhooks").(bool)
+	client.Force = d.Get("force_update").(bool)
+	client.Recreate = d.Get("recreate_pods").(bool)
+	client.CleanupOnFail = d.Get("cleanup_on_fail").(bool)
+	client.MaxHistory = d.Get("max_history").(int)
+
+	if err := client.Run(name); err != nil {
+		return diag.FromErr(err)
+	}
+
+	r, err := getRelease(m, actionConfig, name)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	from, err := getValuesFrom(ctx, d, m)
 	if err != nil {
 		return diag.FromErr(err)
 	}
+
+	if err := setReleaseHashes(d, from); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := setReleaseAttributes(d, r, m, from); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := setReleaseHistory(d, m, actionConfig); err != nil {
+		return diag.FromErr(err)
+	}
+
+	debug("%s Done", logID)
 	return nil
 }
 
@@ -695,6 +1066,47 @@ func resourceReleaseDelete(ctx context.Context, d *schema.ResourceData, meta int
 }
 
 func resourceDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if err := resourceReleaseDiff(ctx, d, meta); err != nil {
+		return err
+	}
+
+	return setUpgradeComputed(d)
+}
+
+// setUpgradeComputed marks the attributes describing the deployed revision
+// as computed when the release is upgraded, any change to the resource
+// making a new revision of the release
+func setUpgradeComputed(d *schema.ResourceDiff) error {
+	if d.Id() == "" {
+		return nil
+	}
+
+	upgrade := false
+
+	// The changes of the arguments are the ones of the diff, the ones
+	// suppressed left out. The computed attributes are set by resourceDiff.
+	for _, key := range d.GetChangedKeysPrefix("") {
+		if !strings.HasPrefix(key, "metadata.") && !strings.HasPrefix(key, "history.") {
+			upgrade = true
+		}
+	}
+	for key, s := range resourceRelease().Schema {
+		if s.Computed && key != "metadata" && key != "history" && d.HasChange(key) {
+			upgrade = true
+		}
+	}
+
+	if !upgrade {
+		return nil
+	}
+
+	if err := d.SetNewComputed("metadata"); err != nil {
+		return err
+	}
+	return d.SetNewComputed("history")
+}
+
+func resourceReleaseDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
 	logID := fmt.Sprintf("[resourceDiff: %s]", d.Get("name").(string))
 	debug("%s Start", logID)
 
@@ -706,25 +1118,119 @@ func resourceDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{})
 		return err
 	}
 
-	cpo, chartName, err := chartPathOptions(d, m)
-	if err != nil {
-		return err
+	// Plan an upgrade when the contents of the files the values are read from change
+	if !d.NewValueKnown("values_files") || !d.NewValueKnown("set_file") {
+		if err := d.SetNewComputed("file_hashes"); err != nil {
+			return err
+		}
+	} else {
+		hashes, err := getFileHashes(d)
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(d.Get("file_hashes").(map[string]interface{}), hashes) {
+			if err := d.SetNew("file_hashes", hashes); err != nil {
+				return err
+			}
+		}
 	}
 
-	// Get Chart metadata, if we fail - we're done
+	// Likewise, plan an upgrade when the data of the values_from ConfigMaps and
+	// Secrets changes. It may not be readable before the release is created,
+	// like when the cluster is created in the same run.
+	var from *valuesFrom
+	if !d.NewValueKnown("values_from") || !d.NewValueKnown("namespace") {
+		if err := d.SetNewComputed("values_from_hashes"); err != nil {
+			return err
+		}
+	} else if from, err = getValuesFrom(ctx, d, m); err != nil {
+		if d.Id() != "" {
+			return err
+		}
+		if err := d.SetNewComputed("values_from_hashes"); err != nil {
+			return err
+		}
+	} else if !reflect.DeepEqual(d.Get("values_from_hashes").(map[string]interface{}), from.hashes) {
+		if err := d.SetNew("values_from_hashes", from.hashes); err != nil {
+			return err
+		}
+	}
+
+	// Likewise, plan an upgrade when the values of set_from_env and
+	// set_from_file change
+	if !d.NewValueKnown("set_from_env") || !d.NewValueKnown("set_from_file") {
+		if err := d.SetNewComputed("set_from_hashes"); err != nil {
+			return err
+		}
+	} else {
+		hashes, err := getSetFromHashes(d)
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(d.Get("set_from_hashes").(map[string]interface{}), hashes) {
+			if err := d.SetNew("set_from_hashes", hashes); err != nil {
+				return err
+			}
+		}
+	}
+
+	// The chart and values of the target revision are only known once the
+	// rollback has been performed
+	if isRollback(d) {
+		if err := checkRollback(d, m); err != nil {
+			return err
+		}
+
+		d.SetNewComputed("manifest")
+		return d.SetNewComputed("version")
+	}
+
+	// After a rollback, the release runs the values of the revision rolled
+	// back to. The configured ones are applied again when they differ.
+	if rollbackApplied(d) && from != nil && valuesKnown(d) {
+		differ, err := rolledBackValuesDiffer(d, from)
+		if err != nil {
+			return err
+		}
+		if differ {
+			d.SetNewComputed("metadata")
+			d.SetNewComputed("history")
+		}
+	}
+
+	cpo, chartName, err := chartPathOptions(d, m)
+	if err != nil {
+		return err
+	}
+
+	// Resolve the version against the repository index, so version constraints
+	// show the concrete version to be installed
+	if err := resolveReleaseVersion(d, m, cpo, chartName); err != nil {
+		return err
+	}
+
+	// Get Chart metadata, if we fail - we're done
 	chart, _, err := getChart(d, meta.(*Meta), chartName, cpo)
 	if err != nil {
 		return nil
 	}
 	debug("%s Got chart", logID)
 
+	// Validates the values against the schemas of the chart and its
+	// subcharts, pointing at the attribute each offending value is set by
+	if valuesKnown(d) {
+		if err := resourceReleaseValidateSchema(d, chart, from); err != nil {
+			return err
+		}
+	}
+
 	// Validates the resource configuration, the values, the chart itself, and
 	// the combination of both.
 	//
 	// Maybe here is not the most canonical place to include a validation
 	// but is the only place to fail in `terraform plan`.
 	if d.Get("lint").(bool) {
-		if err := resourceReleaseValidate(d, meta.(*Meta), cpo); err != nil {
+		if err := resourceReleaseValidate(d, meta.(*Meta), cpo, from); err != nil {
 			return err
 		}
 	}
@@ -786,7 +1292,13 @@ func resourceDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{})
 			client.PostRenderer = pr
 		}
 
-		values, err := getValues(d)
+		// The values_from data is not known until apply
+		if from == nil {
+			d.SetNewComputed("manifest")
+			return d.SetNewComputed("version")
+		}
+
+		values, err := getReleaseValues(d, from)
 		if err != nil {
 			return fmt.Errorf("error getting values for a diff: %v", err)
 		}
@@ -807,8 +1319,15 @@ func resourceDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{})
 		if err != nil {
 			return err
 		}
-		manifest := redactSensitiveValues(string(jsonManifest), d)
-		d.SetNew("manifest", manifest)
+		manifest, err := redactSensitiveValues(string(jsonManifest), d)
+		if err != nil {
+			return err
+		}
+		secrets, err := sensitiveStrings(d, from, values)
+		if err != nil {
+			return err
+		}
+		d.SetNew("manifest", redactSecretValues(manifest, secrets))
 		debug("%s set manifest: %s", logID, jsonManifest)
 	} else {
 		d.Clear("manifest")
@@ -824,7 +1343,7 @@ func resourceDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{})
 	return d.SetNewComputed("version")
 }
 
-func setReleaseAttributes(d *schema.ResourceData, r *release.Release, meta interface{}) error {
+func setReleaseAttributes(d *schema.ResourceData, r *release.Release, meta interface{}, from *valuesFrom) error {
 	d.SetId(r.Name)
 
 	if err := d.Set("version", r.Chart.Metadata.Version); err != nil {
@@ -839,20 +1358,56 @@ func setReleaseAttributes(d *schema.ResourceData, r *release.Release, meta inter
 		return err
 	}
 
+	m := meta.(*Meta)
+
+	// The files may not be readable when refreshing, from another machine
+	// for instance. The values cloaked in the state are cloaked again then.
+	_, filesErr := getFileHashes(d)
+	var filePaths [][]string
+	if filesErr == nil {
+		paths, err := getValuesFilesPaths(d)
+		if err != nil {
+			return err
+		}
+		filePaths = paths
+	} else {
+		debug("could not read the values files of %s: %s", r.Name, filesErr)
+		var previous interface{}
+		if err := json.Unmarshal([]byte(d.Get("metadata.0.values").(string)), &previous); err == nil {
+			filePaths = cloakedPaths(previous, nil)
+		}
+	}
+
+	// The values are cloaked below, the secrets still set are found first
+	var secrets []string
+	if filesErr == nil {
+		s, err := sensitiveStrings(d, from, r.Config)
+		if err != nil {
+			return err
+		}
+		secrets = s
+	}
+
 	cloakSetValues(r.Config, d)
+	cloakSecretValues(r.Config, from.secretPaths)
+	cloakSecretValues(r.Config, filePaths)
 	values, err := json.Marshal(r.Config)
 	if err != nil {
 		return err
 	}
 
-	m := meta.(*Meta)
-	if m.ExperimentEnabled("manifest") {
+	// The manifest is kept as it is when the Secrets or files to redact from
+	// it could not be read
+	if m.ExperimentEnabled("manifest") && !from.unavailable && filesErr == nil {
 		jsonManifest, err := convertYAMLManifestToJSON(r.Manifest)
 		if err != nil {
 			return err
 		}
-		manifest := redactSensitiveValues(string(jsonManifest), d)
-		d.Set("manifest", manifest)
+		manifest, err := redactSensitiveValues(string(jsonManifest), d)
+		if err != nil {
+			return err
+		}
+		d.Set("manifest", redactSecretValues(manifest, secrets))
 	}
 
 	return d.Set("metadata", []map[string]interface{}{{
@@ -866,11 +1421,138 @@ func setReleaseAttributes(d *schema.ResourceData, r *release.Release, meta inter
 	}})
 }
 
+// setReleaseHashes records the hashes of the files the values have been read
+// from, of the set_from_env and set_from_file values and of the values_from
+// data. It is not done on refresh, as changes to them would not be planned
+// otherwise.
+func setReleaseHashes(d *schema.ResourceData, from *valuesFrom) error {
+	if err := d.Set("values_from_hashes", from.hashes); err != nil {
+		return err
+	}
+
+	fileHashes, err := getFileHashes(d)
+	if err != nil {
+		return err
+	}
+
+	if err := d.Set("file_hashes", fileHashes); err != nil {
+		return err
+	}
+
+	setFromHashes, err := getSetFromHashes(d)
+	if err != nil {
+		return err
+	}
+
+	return d.Set("set_from_hashes", setFromHashes)
+}
+
+// resolveReleaseVersion sets the version of the chart to install, resolving
+// the version constraint against the index of the chart repository, and the
+// latest version of the chart
+func resolveReleaseVersion(d *schema.ResourceDiff, m *Meta, cpo *action.ChartPathOptions, chartName string) error {
+	versions, err := getChartVersions(m, cpo, chartName)
+	if err != nil {
+		return err
+	}
+
+	// Repositories not configured yet, like when they are created in the
+	// same run, are left to be read at apply time
+	if versions == nil {
+		return nil
+	}
+
+	cv, err := resolveChartVersion(versions, cpo.Version)
+	if err != nil {
+		return err
+	}
+
+	// Locate the same version that is going to be installed
+	cpo.Version = cv.Version
+	if err := d.SetNew("version", cv.Version); err != nil {
+		return err
+	}
+
+	if d.Id() != "" && !d.HasChange("version") {
+		// The latest version is refreshed when reading the release, a new
+		// version alone should not plan an upgrade
+		return nil
+	}
+
+	latest, err := latestChartVersion(d, versions)
+	if err != nil {
+		return err
+	}
+	return d.SetNew("latest_version", latest)
+}
+
+// latestChartVersion returns the newest version of the chart, including
+// development versions if devel is set
+func latestChartVersion(d resourceGetter, versions repo.ChartVersions) (string, error) {
+	constraint := ""
+	if d.Get("devel").(bool) {
+		constraint = ">0.0.0-0"
+	}
+
+	cv, err := resolveChartVersion(versions, constraint)
+	if err != nil {
+		return "", err
+	}
+	return cv.Version, nil
+}
+
+// setLatestVersion refreshes the latest version of the chart published in the
+// repository
+func setLatestVersion(d *schema.ResourceData, m *Meta) error {
+	cpo, chartName, err := chartPathOptions(d, m)
+	if err != nil {
+		return err
+	}
+
+	versions, err := getChartVersions(m, cpo, chartName)
+	if err != nil {
+		return fmt.Errorf("could not get the versions of chart %q: %s", chartName, err)
+	}
+
+	if versions == nil {
+		return nil
+	}
+
+	latest, err := latestChartVersion(d, versions)
+	if err != nil {
+		return fmt.Errorf("could not get the latest version of chart %q: %s", chartName, err)
+	}
+	return d.Set("latest_version", latest)
+}
+
+// setReleaseHistory sets the revisions of the release kept by Helm, capped by
+// max_history
+func setReleaseHistory(d *schema.ResourceData, m *Meta, cfg *action.Configuration) error {
+	history, err := getReleaseHistory(m, cfg, d.Get("name").(string), d.Get("max_history").(int))
+	if err != nil {
+		return err
+	}
+
+	return d.Set("history", flattenReleaseHistory(history))
+}
+
 func cloakSetValues(config map[string]interface{}, d resourceGetter) {
 	for _, raw := range d.Get("set_sensitive").(*schema.Set).List() {
 		set := raw.(map[string]interface{})
 		cloakSetValue(config, set["name"].(string))
 	}
+
+	for _, raw := range d.Get("set_file").(*schema.Set).List() {
+		set := raw.(map[string]interface{})
+		cloakSetValue(config, set["name"].(string))
+	}
+
+	for _, key := range []string{"set_from_env", "set_from_file"} {
+		for _, raw := range d.Get(key).(*schema.Set).List() {
+			set := raw.(map[string]interface{})
+			cloakSetValue(config, set["name"].(string))
+		}
+	}
 }
 
 const sensitiveContentValue = "(sensitive value)"
@@ -924,6 +1606,12 @@ type resourceGetter interface {
 	Get(string) interface{}
 }
 
+type resourceChangeGetter interface {
+	resourceGetter
+	Id() string
+	HasChange(string) bool
+}
+
 func getVersion(d resourceGetter, m *Meta) (version string) {
 	version = d.Get("version").(string)
 
@@ -942,7 +1630,7 @@ func getChart(d resourceGetter, m *Meta, name string, cpo *action.ChartPathOptio
 	m.Lock()
 	defer m.Unlock()
 
-	path, err := cpo.LocateChart(name, m.Settings)
+	path, err := locateChart(m, name, cpo)
 	if err != nil {
 		return nil, "", err
 	}
@@ -955,6 +1643,15 @@ func getChart(d resourceGetter, m *Meta, name string, cpo *action.ChartPathOptio
 	return c, path, nil
 }
 
+// locateChart returns the local path of the chart, downloading it if needed
+func locateChart(m *Meta, name string, cpo *action.ChartPathOptions) (string, error) {
+	if isOCIChart(name) {
+		return locateOCIChart(m, name, cpo)
+	}
+
+	return cpo.LocateChart(name, m.Settings)
+}
+
 // Merges source and destination map, preferring values from the source map
 // Taken from github.com/helm/pkg/cli/values/options.go
 func mergeMaps(a, b map[string]interface{}) map[string]interface{} {
@@ -979,6 +1676,25 @@ func mergeMaps(a, b map[string]interface{}) map[string]interface{} {
 func getValues(d resourceGetter) (map[string]interface{}, error) {
 	base := map[string]interface{}{}
 
+	valuesFiles, err := getValuesFiles(d)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range valuesFiles {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		currentMap := map[string]interface{}{}
+		if err := yaml.Unmarshal(data, &currentMap); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %s", path, err)
+		}
+
+		base = mergeMaps(base, currentMap)
+	}
+
 	for _, raw := range d.Get("values").([]interface{}) {
 		if raw == nil {
 			continue
@@ -1004,6 +1720,20 @@ func getValues(d resourceGetter) (map[string]interface{}, error) {
 		}
 	}
 
+	for _, raw := range d.Get("set_list").([]interface{}) {
+		set := raw.(map[string]interface{})
+		if err := getListValue(base, set); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, raw := range d.Get("set_file").(*schema.Set).List() {
+		set := raw.(map[string]interface{})
+		if err := getFileValue(base, set); err != nil {
+			return nil, err
+		}
+	}
+
 	for _, raw := range d.Get("set_sensitive").(*schema.Set).List() {
 		set := raw.(map[string]interface{})
 		if err := getValue(base, set); err != nil {
@@ -1011,9 +1741,256 @@ func getValues(d resourceGetter) (map[string]interface{}, error) {
 		}
 	}
 
+	setFromValues, err := getSetFromValues(d)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range sortedKeys(setFromValues) {
+		if err := setRawValue(base, name, setFromValues[name]); err != nil {
+			return nil, fmt.Errorf("failed parsing key %q, %s", name, err)
+		}
+	}
+
 	return base, logValues(base, d)
 }
 
+// getSetFromValues returns the values of the set_from_env and set_from_file
+// blocks, by name. They are read each time, to be kept out of the state.
+func getSetFromValues(d resourceGetter) (map[string]string, error) {
+	values := map[string]string{}
+
+	// A name set twice would silently take either value, the blocks being
+	// sets
+	seen := map[string]string{}
+	for _, key := range []string{"set_from_env", "set_from_file"} {
+		for _, raw := range d.Get(key).(*schema.Set).List() {
+			name := raw.(map[string]interface{})["name"].(string)
+			if other, ok := seen[name]; ok {
+				return nil, fmt.Errorf("key %q is set by both %s and %s", name, other, key)
+			}
+			seen[name] = key
+		}
+	}
+
+	for _, raw := range d.Get("set_from_env").(*schema.Set).List() {
+		set := raw.(map[string]interface{})
+		name := set["name"].(string)
+		env := set["env"].(string)
+
+		value, ok := os.LookupEnv(env)
+		if !ok {
+			return nil, fmt.Errorf("environment variable %s for key %q is not set", env, name)
+		}
+		values[name] = value
+	}
+
+	for _, raw := range d.Get("set_from_file").(*schema.Set).List() {
+		set := raw.(map[string]interface{})
+		name := set["name"].(string)
+		path := set["path"].(string)
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed reading file %s for key %q: %s", path, name, err)
+		}
+		values[name] = string(data)
+	}
+
+	return values, nil
+}
+
+// getSetFromHashes returns salted scrypt hashes of the set_from_env and
+// set_from_file values, by name. Only the hashes are kept in the state, for
+// changes to the values to be planned. The hash of a value is kept as long as
+// it matches, for the hashes to be stable between plan and apply.
+func getSetFromHashes(d resourceGetter) (map[string]interface{}, error) {
+	values, err := getSetFromValues(d)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, _ := d.Get("set_from_hashes").(map[string]interface{})
+
+	hashes := map[string]interface{}{}
+
+	for name, value := range values {
+		if hash, ok := previous[name].(string); ok && setFromHashMatches(hash, value) {
+			hashes[name] = hash
+			continue
+		}
+
+		hash, err := hashSetFromValue(value)
+		if err != nil {
+			return nil, err
+		}
+		hashes[name] = hash
+	}
+
+	return hashes, nil
+}
+
+// hashSetFromValue returns the salt and the scrypt hash of the value, hex
+// encoded and separated by a colon. Unlike a plain SHA-256, it is costly to
+// guess low-entropy values from the hash.
+func hashSetFromValue(value string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key, err := scrypt.Key([]byte(value), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(salt) + ":" + hex.EncodeToString(key), nil
+}
+
+func setFromHashMatches(hash, value string) bool {
+	parts := strings.SplitN(hash, ":", 2)
+	if len(parts) != 2 {
+		return false
+	}
+
+	salt, err := hex.DecodeString(parts[0])
+	if err != nil {
+		return false
+	}
+
+	key, err := scrypt.Key([]byte(value), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(hex.EncodeToString(key)), []byte(parts[1])) == 1
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// getValuesFiles returns the paths of the values_files, with the matches of
+// each glob pattern in lexical order
+func getValuesFiles(d resourceGetter) ([]string, error) {
+	var paths []string
+
+	for _, raw := range d.Get("values_files").([]interface{}) {
+		pattern, _ := raw.(string)
+		if pattern == "" {
+			continue
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid values_files pattern %q: %s", pattern, err)
+		}
+
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("values_files %q does not match any file", pattern)
+		}
+
+		paths = append(paths, matches...)
+	}
+
+	return paths, nil
+}
+
+// getValuesFilesPaths returns the paths of the values set by the
+// values_files, for their contents to be cloaked like the values read from
+// Secrets
+func getValuesFilesPaths(d resourceGetter) ([][]string, error) {
+	values, err := getValuesFilesValues(d)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths [][]string
+	for _, v := range values {
+		paths = append(paths, v.path)
+	}
+
+	return paths, nil
+}
+
+// getValuesFilesValues returns the values set by the values_files, by path
+func getValuesFilesValues(d resourceGetter) ([]secretValue, error) {
+	valuesFiles, err := getValuesFiles(d)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []secretValue
+
+	for _, path := range valuesFiles {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		values := map[string]interface{}{}
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %s", path, err)
+		}
+
+		result = append(result, leafValues(values)...)
+	}
+
+	return result, nil
+}
+
+// sensitiveStrings returns the strings of the values read from Secrets and
+// values files which are still set in values, to be redacted from the
+// manifest and the messages
+func sensitiveStrings(d resourceGetter, from *valuesFrom, values map[string]interface{}) ([]string, error) {
+	files, err := getValuesFilesValues(d)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := append(append([]secretValue{}, from.secrets...), files...)
+	return secretStrings(secrets, values), nil
+}
+
+// getFileHashes returns the SHA-256 of the contents of the values_files and
+// set_file files, by path. Only the hashes are kept in the state, for changes
+// to the files to be planned.
+func getFileHashes(d resourceGetter) (map[string]interface{}, error) {
+	paths, err := getValuesFiles(d)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, raw := range d.Get("set_file").(*schema.Set).List() {
+		set := raw.(map[string]interface{})
+		paths = append(paths, set["path"].(string))
+	}
+
+	hashes := map[string]interface{}{}
+
+	for _, path := range paths {
+		// the path may not be known yet
+		if path == "" {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		sum := sha256.Sum256(data)
+		hashes[path] = hex.EncodeToString(sum[:])
+	}
+
+	return hashes, nil
+}
+
 func getValue(base, set map[string]interface{}) error {
 	name := set["name"].(string)
 	value := set["value"].(string)
@@ -1028,6 +2005,18 @@ func getValue(base, set map[string]interface{}) error {
 		if err := strvals.ParseIntoString(fmt.Sprintf("%s=%s", name, value), base); err != nil {
 			return fmt.Errorf("failed parsing key %q with value %s, %s", name, value, err)
 		}
+	case "json":
+		var v interface{}
+		if err := json.Unmarshal([]byte(value), &v); err != nil {
+			return fmt.Errorf("failed parsing key %q with value %s, %s", name, value, err)
+		}
+		if err := setRawValue(base, name, v); err != nil {
+			return fmt.Errorf("failed parsing key %q with value %s, %s", name, value, err)
+		}
+	case "literal":
+		if err := setRawValue(base, name, value); err != nil {
+			return fmt.Errorf("failed parsing key %q with value %s, %s", name, value, err)
+		}
 	default:
 		return fmt.Errorf("unexpected type: %s", valueType)
 	}
@@ -1035,17 +2024,56 @@ func getValue(base, set map[string]interface{}) error {
 	return nil
 }
 
+func getFileValue(base, set map[string]interface{}) error {
+	name := set["name"].(string)
+	path := set["path"].(string)
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed reading file %s for key %q: %s", path, name, err)
+	}
+
+	if err := setRawValue(base, name, string(data)); err != nil {
+		return fmt.Errorf("failed parsing key %q with file %s, %s", name, path, err)
+	}
+
+	return nil
+}
+
+func getListValue(base, set map[string]interface{}) error {
+	name := set["name"].(string)
+	value := []interface{}{}
+
+	for _, v := range set["value"].([]interface{}) {
+		// empty list elements are null in the configuration
+		s, _ := v.(string)
+		value = append(value, s)
+	}
+
+	if err := setRawValue(base, name, value); err != nil {
+		return fmt.Errorf("failed parsing key %q with list value %v, %s", name, value, err)
+	}
+
+	return nil
+}
+
+// setRawValue sets the value at the strvals path name, without parsing the
+// value the way --set does. The value is handed to the parser by a reader,
+// like Helm does for --set-file.
+func setRawValue(base map[string]interface{}, name string, value interface{}) error {
+	reader := func([]rune) (interface{}, error) {
+		return value, nil
+	}
+
+	return strvals.ParseIntoFile(name+"=-", base, reader)
+}
+
 func logValues(values map[string]interface{}, d resourceGetter) error {
-	// copy array to avoid change values by the cloak function.
-	asJSON, _ := json.Marshal(values)
-	var c map[string]interface{}
-	err := json.Unmarshal(asJSON, &c)
+	c, err := cloakValues(values, d)
 	if err != nil {
 		return err
 	}
 
-	cloakSetValues(c, d)
-
 	y, err := yaml.Marshal(c)
 	if err != nil {
 		return err
@@ -1059,6 +2087,20 @@ func logValues(values map[string]interface{}, d resourceGetter) error {
 	return nil
 }
 
+// cloakValues returns a copy of the values with the sensitive values cloaked
+func cloakValues(values map[string]interface{}, d resourceGetter) (map[string]interface{}, error) {
+	// copy array to avoid change values by the cloak function.
+	asJSON, _ := json.Marshal(values)
+	var c map[string]interface{}
+	err := json.Unmarshal(asJSON, &c)
+	if err != nil {
+		return nil, err
+	}
+
+	cloakSetValues(c, d)
+	return c, nil
+}
+
 func getRelease(m *Meta, cfg *action.Configuration, name string) (*release.Release, error) {
 	debug("%s getRelease wait for lock", name)
 	m.Lock()
@@ -1089,6 +2131,10 @@ func getRelease(m *Meta, cfg *action.Configuration, name string) (*release.Relea
 }
 
 func resolveChartName(repository, name string) (string, string, error) {
+	if isOCIChart(repository) {
+		return "", fmt.Sprintf("%s/%s", strings.TrimSuffix(repository, "/"), name), nil
+	}
+
 	_, err := url.ParseRequestURI(repository)
 	if err == nil {
 		return repository, name, nil
@@ -1173,7 +2219,7 @@ func resourceHelmReleaseImportState(ctx context.Context, d *schema.ResourceData,
 		}
 	}
 
-	if err := setReleaseAttributes(d, r, m); err != nil {
+	if err := setReleaseAttributes(d, r, m, newValuesFrom()); err != nil {
 		return nil, err
 	}
 
@@ -1190,13 +2236,19 @@ func parseImportIdentifier(id string) (string, string, error) {
 	return parts[0], parts[1], nil
 }
 
-func resourceReleaseValidate(d resourceGetter, meta interface{}, cpo *action.ChartPathOptions) error {
+// resourceReleaseValidate lints the chart with the values of the release. The
+// values_from data is left out when it is not known yet.
+func resourceReleaseValidate(d resourceGetter, meta interface{}, cpo *action.ChartPathOptions, from *valuesFrom) error {
 	cpo, name, err := chartPathOptions(d, meta.(*Meta))
 	if err != nil {
 		return fmt.Errorf("malformed values: \n\t%s", err)
 	}
 
-	values, err := getValues(d)
+	if from == nil {
+		from = newValuesFrom()
+	}
+
+	values, err := getReleaseValues(d, from)
 	if err != nil {
 		return err
 	}
@@ -1204,8 +2256,86 @@ func resourceReleaseValidate(d resourceGetter, meta interface{}, cpo *action.Cha
 	return lintChart(meta.(*Meta), name, cpo, values)
 }
 
+// resourceReleaseValidateSchema validates the values of the release against
+// the schemas of the chart when planning, failing with the first violation.
+// The values not known until apply are left to be validated then.
+//
+// When the values_from data is not known yet, the values are validated
+// without it, only reporting the values set by the other attributes, which
+// take precedence over it. Values missing or set by the chart may be set by
+// values_from.
+func resourceReleaseValidateSchema(d resourceGetter, c *chart.Chart, from *valuesFrom) error {
+	partial := from == nil
+	if partial {
+		from = newValuesFrom()
+	}
+
+	values, err := getReleaseValues(d, from)
+	if err != nil {
+		debug("could not get the values to validate against the chart schema: %s", err)
+		return nil
+	}
+
+	if hasUnknownValue(values) {
+		return nil
+	}
+
+	secrets, err := sensitiveStrings(d, from, values)
+	if err != nil {
+		return err
+	}
+
+	violations, err := checkValuesSchema(d, c, values, secrets)
+	if err != nil {
+		return err
+	}
+
+	if partial {
+		var set []valuesSchemaViolation
+		for _, v := range violations {
+			if v.source != "" && v.kind != "required" {
+				set = append(set, v)
+			}
+		}
+		violations = set
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return valuesSchemaError(violations)
+}
+
+// validateReleaseValues validates the values of the release against the
+// schemas of the chart before installing or upgrading it, reporting each
+// violation, the ones not known when planning included
+func validateReleaseValues(d resourceGetter, c *chart.Chart, from *valuesFrom, values map[string]interface{}) diag.Diagnostics {
+	secrets, err := sensitiveStrings(d, from, values)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	violations, err := checkValuesSchema(d, c, values, secrets)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	return valuesSchemaDiagnostics(violations)
+}
+
+// valuesKnown tells if the attributes the values are read from are known
+func valuesKnown(d *schema.ResourceDiff) bool {
+	for _, key := range []string{"values", "values_files", "set", "set_list", "set_file", "set_sensitive", "set_from_env", "set_from_file"} {
+		if !d.NewValueKnown(key) {
+			return false
+		}
+	}
+	return true
+}
+
 func lintChart(m *Meta, name string, cpo *action.ChartPathOptions, values map[string]interface{}) (err error) {
-	path, err := cpo.LocateChart(name, m.Settings)
+	path, err := locateChart(m, name, cpo)
 	if err != nil {
 		return err
 	}
@@ -1,10 +1,15 @@
 package helm
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
@@ -13,6 +18,7 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	"github.com/pkg/errors"
 
@@ -22,6 +28,8 @@ import (
 	"helm.sh/helm/v3/pkg/releaseutil"
 	"helm.sh/helm/v3/pkg/repo"
 
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 )
 
@@ -223,6 +231,91 @@ func TestAccResourceRelease_update(t *testing.T) {
 					resource.TestCheckResourceAttr("helm_release.test", "metadata.0.version", "2.0.0"),
 					resource.TestCheckResourceAttr("helm_release.test", "status", release.StatusDeployed.String()),
 					resource.TestCheckResourceAttr("helm_release.test", "version", "2.0.0"),
+					resource.TestCheckResourceAttr("helm_release.test", "history.#", "2"),
+					resource.TestCheckResourceAttr("helm_release.test", "history.0.status", release.StatusSuperseded.String()),
+					resource.TestCheckResourceAttr("helm_release.test", "history.0.version", "1.2.3"),
+					resource.TestCheckResourceAttr("helm_release.test", "history.1.status", release.StatusDeployed.String()),
+					resource.TestCheckResourceAttr("helm_release.test", "history.1.version", "2.0.0"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccResourceRelease_versionConstraint(t *testing.T) {
+	name := randName("constraint")
+	namespace := createRandomNamespace(t)
+	defer deleteNamespace(t, namespace)
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckHelmReleaseDestroy(namespace),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccHelmReleaseConfigBasic(testResourceName, namespace, name, "~1.2"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("helm_release.test", "metadata.0.version", "1.2.3"),
+					resource.TestCheckResourceAttr("helm_release.test", "version", "1.2.3"),
+					resource.TestCheckResourceAttr("helm_release.test", "latest_version", "2.0.0"),
+				),
+			},
+			{
+				Config:   testAccHelmReleaseConfigBasic(testResourceName, namespace, name, "~1.2"),
+				PlanOnly: true,
+			},
+			{
+				Config: testAccHelmReleaseConfigBasic(testResourceName, namespace, name, ">=1.2"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("helm_release.test", "metadata.0.revision", "2"),
+					resource.TestCheckResourceAttr("helm_release.test", "version", "2.0.0"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccResourceRelease_rollback(t *testing.T) {
+	name := randName("rollback")
+	namespace := createRandomNamespace(t)
+	defer deleteNamespace(t, namespace)
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckHelmReleaseDestroy(namespace),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccHelmReleaseConfigBasic(testResourceName, namespace, name, "1.2.3"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("helm_release.test", "metadata.0.revision", "1"),
+					resource.TestCheckResourceAttr("helm_release.test", "metadata.0.version", "1.2.3"),
+				),
+			},
+			{
+				Config: testAccHelmReleaseConfigBasic(testResourceName, namespace, name, "2.0.0"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("helm_release.test", "metadata.0.revision", "2"),
+					resource.TestCheckResourceAttr("helm_release.test", "metadata.0.version", "2.0.0"),
+				),
+			},
+			{
+				// the version has to match the one of the revision
+				Config:      testAccHelmReleaseConfigRollback(testResourceName, namespace, name, "2.0.0", 1, ""),
+				ExpectError: regexp.MustCompile(`set version to "1.2.3"`),
+			},
+			{
+				// other changes would be dropped by the rollback
+				Config:      testAccHelmReleaseConfigRollback(testResourceName, namespace, name, "1.2.3", 1, "Changed"),
+				ExpectError: regexp.MustCompile(`cannot be changed along with description`),
+			},
+			{
+				Config: testAccHelmReleaseConfigRollback(testResourceName, namespace, name, "1.2.3", 1, ""),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("helm_release.test", "metadata.0.revision", "3"),
+					resource.TestCheckResourceAttr("helm_release.test", "metadata.0.version", "1.2.3"),
+					resource.TestCheckResourceAttr("helm_release.test", "version", "1.2.3"),
+					resource.TestCheckResourceAttr("helm_release.test", "status", release.StatusDeployed.String()),
 				),
 			},
 		},
@@ -312,6 +405,68 @@ func TestAccResourceRelease_cloakValues(t *testing.T) {
 	})
 }
 
+func TestAccResourceRelease_valuesFrom(t *testing.T) {
+	name := randName("test-values-from")
+	namespace := createRandomNamespace(t)
+	defer deleteNamespace(t, namespace)
+
+	writeSecret := func(value string) {
+		secret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "credentials"},
+			StringData: map[string]string{"foo": value},
+		}
+		secrets := client.CoreV1().Secrets(namespace)
+		if _, err := secrets.Update(context.TODO(), secret, metav1.UpdateOptions{}); err == nil {
+			return
+		}
+		if _, err := secrets.Create(context.TODO(), secret, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("Could not create secret: %s", err)
+		}
+	}
+	writeSecret("bar")
+
+	config := fmt.Sprintf(`
+		resource "helm_release" "test" {
+			name       = %q
+			namespace  = %q
+			repository = %q
+			chart      = "test-chart"
+			version    = "1.2.3"
+
+			values_from {
+				kind        = "Secret"
+				name        = "credentials"
+				key         = "foo"
+				target_path = "foo"
+			}
+		}
+	`, name, namespace, testRepositoryURL)
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckHelmReleaseDestroy(namespace),
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("helm_release.test", "metadata.0.revision", "1"),
+					resource.TestCheckResourceAttr("helm_release.test", "metadata.0.values", "{\"foo\":\"(sensitive value)\"}"),
+					resource.TestCheckResourceAttr("helm_release.test", "values_from_hashes.%", "1"),
+				),
+			},
+			{
+				PreConfig: func() { writeSecret("baz") },
+				Config:    config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("helm_release.test", "metadata.0.revision", "2"),
+					resource.TestCheckResourceAttr("helm_release.test", "metadata.0.values", "{\"foo\":\"(sensitive value)\"}"),
+				),
+			},
+		},
+	})
+}
+
 func TestAccResourceRelease_updateMultipleValues(t *testing.T) {
 	name := randName("test-update-multiple-values")
 	namespace := createRandomNamespace(t)
@@ -633,6 +788,35 @@ func testAccHelmReleaseConfigBasic(resource, ns, name, version string) string {
 	`, resource, name, ns, testRepositoryURL, version)
 }
 
+func testAccHelmReleaseConfigRollback(resource, ns, name, version string, revision int, description string) string {
+	if description == "" {
+		description = "Test"
+	}
+
+	return fmt.Sprintf(`
+		resource "helm_release" "%s" {
+			name        = %q
+			namespace   = %q
+			description = %q
+			repository  = %q
+			chart       = "test-chart"
+			version     = %q
+
+			set {
+				name = "foo"
+				value = "bar"
+			}
+
+			set {
+				name = "fizz"
+				value = 1337
+			}
+
+			rollback_to_revision = %d
+		}
+	`, resource, name, ns, description, testRepositoryURL, version, revision)
+}
+
 func testAccHelmReleaseConfigValues(resource, ns, name, chart, version string, values []string) string {
 	vals := make([]string, len(values))
 	for i, v := range values {
@@ -728,6 +912,328 @@ func TestGetValuesString(t *testing.T) {
 	}
 }
 
+func TestGetValuesJSONAndLiteral(t *testing.T) {
+	d := resourceRelease().Data(nil)
+	err := d.Set("set", []interface{}{
+		map[string]interface{}{"name": "tolerations", "value": `[{"key":"a","operator":"Exists"}]`, "type": "json"},
+		map[string]interface{}{"name": "resources.limits", "value": `{"cpu":"1","memory":"1Gi"}`, "type": "json"},
+		map[string]interface{}{"name": "annotations.example\\.com/list", "value": "a,b,{c}", "type": "literal"},
+	})
+	if err != nil {
+		t.Fatalf("error setting values: %s", err)
+	}
+
+	values, err := getValues(d)
+	if err != nil {
+		t.Fatalf("error getValues: %s", err)
+	}
+
+	expected := map[string]interface{}{
+		"tolerations": []interface{}{
+			map[string]interface{}{"key": "a", "operator": "Exists"},
+		},
+		"resources": map[string]interface{}{
+			"limits": map[string]interface{}{"cpu": "1", "memory": "1Gi"},
+		},
+		"annotations": map[string]interface{}{
+			"example.com/list": "a,b,{c}",
+		},
+	}
+
+	if !reflect.DeepEqual(values, expected) {
+		t.Fatalf("error merging values, expected %v, got %v", expected, values)
+	}
+
+	err = d.Set("set", []interface{}{
+		map[string]interface{}{"name": "foo", "value": "{not json", "type": "json"},
+	})
+	if err != nil {
+		t.Fatalf("error setting values: %s", err)
+	}
+
+	if _, err := getValues(d); err == nil {
+		t.Fatal("expected an error for an invalid JSON value")
+	}
+}
+
+func TestGetValuesList(t *testing.T) {
+	d := resourceRelease().Data(nil)
+	err := d.Set("values", []string{"hosts: [old]\ningress:\n  hosts: [old]\n"})
+	if err != nil {
+		t.Fatalf("error setting values: %s", err)
+	}
+	err = d.Set("set_list", []interface{}{
+		map[string]interface{}{"name": "hosts", "value": []interface{}{"a.example.com", "b,c", "42"}},
+		map[string]interface{}{"name": "ingress.hosts", "value": []interface{}{}},
+	})
+	if err != nil {
+		t.Fatalf("error setting values: %s", err)
+	}
+
+	values, err := getValues(d)
+	if err != nil {
+		t.Fatalf("error getValues: %s", err)
+	}
+
+	expected := map[string]interface{}{
+		"hosts": []interface{}{"a.example.com", "b,c", "42"},
+		"ingress": map[string]interface{}{
+			"hosts": []interface{}{},
+		},
+	}
+
+	if !reflect.DeepEqual(values, expected) {
+		t.Fatalf("error merging values, expected %v, got %v", expected, values)
+	}
+}
+
+func TestCloakSetValuesJSON(t *testing.T) {
+	d := resourceRelease().Data(nil)
+	err := d.Set("set_sensitive", []interface{}{
+		map[string]interface{}{"name": "credentials", "value": `{"user":"admin","password":"hunter2"}`, "type": "json"},
+	})
+	if err != nil {
+		t.Fatalf("error setting values: %v", err)
+	}
+
+	values, err := getValues(d)
+	if err != nil {
+		t.Fatalf("error getValues: %s", err)
+	}
+
+	cloaked, err := cloakValues(values, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cloaked["credentials"] != sensitiveContentValue {
+		t.Fatalf("error cloak values, expected %q, got %v", sensitiveContentValue, cloaked["credentials"])
+	}
+
+	masked, err := redactSensitiveValues(`{"data":{"user":"admin","password":"hunter2"}}`, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(masked, "admin") || strings.Contains(masked, "hunter2") {
+		t.Fatalf("expected the strings of the JSON value to be redacted, got %s", masked)
+	}
+}
+
+func TestGetValuesFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"values-a.yaml": "foo: a\nfirst: present\n",
+		"values-b.yaml": "foo: b\nsecond: present\n",
+		"tls.crt":       "-----BEGIN CERTIFICATE-----\nMIIB,{x}\n-----END CERTIFICATE-----\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d := resourceRelease().Data(nil)
+	err := d.Set("values_files", []string{filepath.Join(dir, "values-*.yaml")})
+	if err != nil {
+		t.Fatalf("error setting values: %v", err)
+	}
+	err = d.Set("values", []string{"foo: inline"})
+	if err != nil {
+		t.Fatalf("error setting values: %v", err)
+	}
+	err = d.Set("set_file", []interface{}{
+		map[string]interface{}{"name": "tls.cert", "path": filepath.Join(dir, "tls.crt")},
+	})
+	if err != nil {
+		t.Fatalf("error setting values: %v", err)
+	}
+
+	values, err := getValues(d)
+	if err != nil {
+		t.Fatalf("error getValues: %s", err)
+	}
+
+	expected := map[string]interface{}{
+		"foo":    "inline",
+		"first":  "present",
+		"second": "present",
+		"tls":    map[string]interface{}{"cert": files["tls.crt"]},
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Fatalf("error merging values, expected %v, got %v", expected, values)
+	}
+
+	cloaked, err := cloakValues(values, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cloaked["tls"].(map[string]interface{})["cert"] != sensitiveContentValue {
+		t.Fatalf("error cloak values, expected %q, got %v", sensitiveContentValue, cloaked["tls"])
+	}
+
+	paths, err := getValuesFilesPaths(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloakSecretValues(cloaked, paths)
+	if cloaked["first"] != sensitiveContentValue || cloaked["foo"] != sensitiveContentValue {
+		t.Fatalf("expected the values of the values files to be cloaked, got %v", cloaked)
+	}
+
+	manifest, err := json.Marshal(map[string]interface{}{"data": map[string]interface{}{"tls.crt": files["tls.crt"]}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	masked, err := redactSensitiveValues(string(manifest), d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(masked, "MIIB") {
+		t.Fatalf("expected the contents of the set_file file to be redacted, got %s", masked)
+	}
+
+	// foo is overridden by values, the strings of the files still set are
+	// redacted from the manifest
+	secrets, err := sensitiveStrings(d, newValuesFrom(), values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"present", "present"}; !reflect.DeepEqual(secrets, expected) {
+		t.Fatalf("expected the strings of the values files %v, got %v", expected, secrets)
+	}
+	if masked := redactSecretValues(`{"data":{"first":"present"}}`, secrets); strings.Contains(masked, "present") {
+		t.Fatalf("expected the values of the values files to be redacted, got %s", masked)
+	}
+
+	hashes, err := getFileHashes(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hashes) != 3 {
+		t.Fatalf("expected 3 hashes, got %v", hashes)
+	}
+	// sha256 of "foo: a\nfirst: present\n"
+	if h := hashes[filepath.Join(dir, "values-a.yaml")]; h != "2c995b4d2b352be39e67d4aed892c4cd9f6c95a5112d9723db0df04813f11d71" {
+		t.Fatalf("unexpected hash of values-a.yaml %v", h)
+	}
+
+	err = d.Set("values_files", []string{filepath.Join(dir, "missing-*.yaml")})
+	if err != nil {
+		t.Fatalf("error setting values: %v", err)
+	}
+	if _, err := getValues(d); err == nil {
+		t.Fatal("expected an error for a pattern matching no files")
+	}
+}
+
+func TestGetValuesSetFrom(t *testing.T) {
+	os.Setenv("TEST_HELM_DB_PASSWORD", "hunter2,{x}")
+	defer os.Unsetenv("TEST_HELM_DB_PASSWORD")
+
+	path := filepath.Join(t.TempDir(), "token")
+	if err := ioutil.WriteFile(path, []byte("s3cr3t-token"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	d := resourceRelease().Data(nil)
+	err := d.Set("set_from_env", []interface{}{
+		map[string]interface{}{"name": "db.password", "env": "TEST_HELM_DB_PASSWORD"},
+	})
+	if err != nil {
+		t.Fatalf("error setting values: %v", err)
+	}
+	err = d.Set("set_from_file", []interface{}{
+		map[string]interface{}{"name": "api.token", "path": path},
+	})
+	if err != nil {
+		t.Fatalf("error setting values: %v", err)
+	}
+
+	values, err := getValues(d)
+	if err != nil {
+		t.Fatalf("error getValues: %s", err)
+	}
+
+	expected := map[string]interface{}{
+		"db":  map[string]interface{}{"password": "hunter2,{x}"},
+		"api": map[string]interface{}{"token": "s3cr3t-token"},
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Fatalf("error merging values, expected %v, got %v", expected, values)
+	}
+
+	cloaked, err := cloakValues(values, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cloaked["db"].(map[string]interface{})["password"] != sensitiveContentValue ||
+		cloaked["api"].(map[string]interface{})["token"] != sensitiveContentValue {
+		t.Fatalf("error cloak values, got %v", cloaked)
+	}
+
+	// the token is base64 encoded in the secret
+	masked, err := redactSensitiveValues(`{"data":{"token":"czNjcjN0LXRva2Vu"},"stringData":{"password":"hunter2,{x}"}}`, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(masked, "czNjcjN0LXRva2Vu") || strings.Contains(masked, "hunter2") {
+		t.Fatalf("expected the values to be redacted, got %s", masked)
+	}
+	if expected := `{"data":{"token":"(sensitive value)"},"stringData":{"password":"(sensitive value)"}}`; masked != expected {
+		t.Fatalf("expected the values to be replaced without a hash, got %s", masked)
+	}
+
+	hashes, err := getSetFromHashes(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hashes) != 2 || hashes["db.password"] == "" || hashes["api.token"] == "" {
+		t.Fatalf("unexpected hashes %v", hashes)
+	}
+
+	if strings.Contains(hashes["db.password"].(string), fmt.Sprintf("%x", sha256.Sum256([]byte("hunter2,{x}")))) {
+		t.Fatalf("expected the hashes to be salted, got %v", hashes)
+	}
+
+	// the hashes of unchanged values are kept
+	if err := d.Set("set_from_hashes", hashes); err != nil {
+		t.Fatal(err)
+	}
+	same, err := getSetFromHashes(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(same, hashes) {
+		t.Fatalf("expected the hashes to be kept, got %v and %v", hashes, same)
+	}
+
+	// rotating a value changes its hash
+	os.Setenv("TEST_HELM_DB_PASSWORD", "rotated")
+	rotated, err := getSetFromHashes(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated["db.password"] == hashes["db.password"] || rotated["api.token"] != hashes["api.token"] {
+		t.Fatalf("expected only the hash of db.password to change, got %v and %v", hashes, rotated)
+	}
+
+	os.Unsetenv("TEST_HELM_DB_PASSWORD")
+	if _, err := getValues(d); err == nil {
+		t.Fatal("expected an error for an unset environment variable")
+	}
+
+	// a key set by both blocks is rejected
+	err = d.Set("set_from_env", []interface{}{
+		map[string]interface{}{"name": "api.token", "env": "HOME"},
+	})
+	if err != nil {
+		t.Fatalf("error setting values: %v", err)
+	}
+	if _, err := getSetFromHashes(d); err == nil {
+		t.Fatal("expected an error for a key set by both set_from_env and set_from_file")
+	}
+}
+
 func TestCloakSetValues(t *testing.T) {
 	d := resourceRelease().Data(nil)
 	err := d.Set("set_sensitive", []interface{}{
@@ -1287,3 +1793,87 @@ func removeSubcharts(chartName string) error {
 	}
 	return os.RemoveAll(chartsPath)
 }
+
+func TestSetUpgradeComputed(t *testing.T) {
+	r := &schema.Resource{
+		Schema: resourceRelease().Schema,
+		CustomizeDiff: func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+			return setUpgradeComputed(d)
+		},
+	}
+
+	d := schema.TestResourceDataRaw(t, resourceRelease().Schema, map[string]interface{}{
+		"name":        "test",
+		"chart":       "test-chart",
+		"description": "first",
+	})
+	d.SetId("test")
+	d.Set("status", release.StatusDeployed.String())
+	d.Set("metadata", []map[string]interface{}{{"revision": 1}})
+	d.Set("history", []map[string]interface{}{{"revision": 1}})
+	state := d.State()
+	for _, key := range []string{"file_hashes", "set_from_hashes", "values_from_hashes"} {
+		state.Attributes[key+".%"] = "0"
+	}
+	// a suppressed difference does not upgrade the release
+	state.Attributes["keyring"] = ""
+
+	for description, upgrade := range map[string]bool{"first": false, "second": true} {
+		config := terraform.NewResourceConfigRaw(map[string]interface{}{
+			"name":        "test",
+			"chart":       "test-chart",
+			"description": description,
+		})
+
+		diff, err := r.Diff(context.Background(), state, config, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		computed := diff != nil && diff.Attributes["history.#"] != nil && diff.Attributes["history.#"].NewComputed &&
+			diff.Attributes["metadata.#"] != nil && diff.Attributes["metadata.#"].NewComputed
+		if computed != upgrade {
+			t.Errorf("expected history and metadata to be computed %v for description %q, got %v", upgrade, description, diff)
+		}
+	}
+}
+
+func TestRolledBackValuesDiffer(t *testing.T) {
+	var differ bool
+	r := &schema.Resource{
+		Schema: resourceRelease().Schema,
+		CustomizeDiff: func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+			if !rollbackApplied(d) {
+				return fmt.Errorf("expected the rollback to be applied")
+			}
+			var err error
+			differ, err = rolledBackValuesDiffer(d, newValuesFrom())
+			return err
+		},
+	}
+
+	raw := map[string]interface{}{
+		"name":                 "test",
+		"chart":                "test-chart",
+		"rollback_to_revision": 3,
+		"values":               []interface{}{"replicaCount: 2\n"},
+		"set_sensitive": []interface{}{
+			map[string]interface{}{"name": "password", "value": "hunter2", "type": ""},
+		},
+	}
+
+	d := schema.TestResourceDataRaw(t, resourceRelease().Schema, raw)
+	d.SetId("test")
+	d.Set("metadata", []map[string]interface{}{{"revision": 4, "values": `{"password":"(sensitive value)","replicaCount":1}`}})
+	state := d.State()
+
+	for replicas, expected := range map[string]bool{"1": false, "2": true} {
+		raw["values"] = []interface{}{"replicaCount: " + replicas + "\n"}
+		if _, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), nil); err != nil {
+			t.Fatal(err)
+		}
+		if differ != expected {
+			t.Errorf("expected the values to differ %v with %s replicas, got %v", expected, replicas, differ)
+		}
+	}
+}
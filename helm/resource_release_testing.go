@@ -0,0 +1,261 @@
+package helm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	helmtime "helm.sh/helm/v3/pkg/time"
+)
+
+func resourceReleaseTesting() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceReleaseTestingCreate,
+		ReadContext:   resourceReleaseTestingRead,
+		DeleteContext: resourceReleaseTestingDelete,
+		CustomizeDiff: resourceReleaseTestingDiff,
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name of the release to test.",
+			},
+			"namespace": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Namespace of the release to test.",
+				DefaultFunc: schema.EnvDefaultFunc("HELM_NAMESPACE", "default"),
+			},
+			"timeout": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				ForceNew:    true,
+				Default:     defaultAttributes["timeout"],
+				Description: "Time in seconds to wait for any individual kubernetes operation.",
+			},
+			"triggers": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Arbitrary map of values that, when changed, will run the tests again.",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"revision": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "The revision of the release that has been tested, the tests are run again when the release is upgraded.",
+			},
+			"tests": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The last run of each test hook of the release.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The name of the test hook.",
+						},
+						"phase": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Whether the test completed successfully.",
+						},
+						"started_at": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The time the test was started.",
+						},
+						"completed_at": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The time the test was completed.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func resourceReleaseTestingCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	logID := fmt.Sprintf("[resourceReleaseTestingCreate: %s]", d.Get("name").(string))
+	debug("%s Started", logID)
+
+	m := meta.(*Meta)
+	n := d.Get("namespace").(string)
+
+	actionConfig, err := m.GetHelmConfiguration(n)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	client := action.NewReleaseTesting(actionConfig)
+	client.Namespace = n
+	client.Timeout = time.Duration(d.Get("timeout").(int)) * time.Second
+
+	name := d.Get("name").(string)
+
+	debug("%s Running tests", logID)
+	r, err := client.Run(name)
+	if err != nil && r == nil {
+		return diag.FromErr(err)
+	}
+
+	if err != nil {
+		var logs bytes.Buffer
+		if logsErr := client.GetPodLogs(&logs, r); logsErr != nil {
+			fmt.Fprintf(&logs, "%s\n", logsErr)
+		}
+
+		return diag.Diagnostics{
+			{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("Tests of Helm release %q failed: %s", name, err),
+				Detail:   logs.String(),
+			},
+		}
+	}
+
+	d.SetId(r.Name)
+
+	if err := setReleaseTestingAttributes(d, r); err != nil {
+		return diag.FromErr(err)
+	}
+
+	debug("%s Done", logID)
+
+	return nil
+}
+
+func resourceReleaseTestingRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	m := meta.(*Meta)
+	n := d.Get("namespace").(string)
+
+	actionConfig, err := m.GetHelmConfiguration(n)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// The tests are the ones run against the tested revision, later upgrades
+	// of the release are planned as a replacement by resourceReleaseTestingDiff
+	r, err := getReleaseRevision(m, actionConfig, d.Get("name").(string), d.Get("revision").(int))
+	if err == errReleaseNotFound {
+		d.SetId("")
+		return nil
+	} else if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := setReleaseTestingAttributes(d, r); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+func resourceReleaseTestingDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" {
+		return nil
+	}
+
+	m := meta.(*Meta)
+	actionConfig, err := m.GetHelmConfiguration(d.Get("namespace").(string))
+	if err != nil {
+		return err
+	}
+
+	// Test the release again once it has been upgraded or rolled back since
+	// the tested revision
+	r, err := getRelease(m, actionConfig, d.Get("name").(string))
+	if err == errReleaseNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if r.Version == d.Get("revision").(int) {
+		return nil
+	}
+
+	debug("[resourceReleaseTestingDiff: %s] Release upgraded to revision %d", r.Name, r.Version)
+	if err := d.SetNew("revision", r.Version); err != nil {
+		return err
+	}
+
+	return d.ForceNew("revision")
+}
+
+func resourceReleaseTestingDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	// The test pods are handled by the hook delete policies of the chart,
+	// there is nothing else to clean up.
+	d.SetId("")
+	return nil
+}
+
+// getReleaseRevision gets the given revision of a release, or its latest one
+// if revision is 0
+func getReleaseRevision(m *Meta, cfg *action.Configuration, name string, revision int) (*release.Release, error) {
+	if revision == 0 {
+		return getRelease(m, cfg, name)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	get := action.NewGet(cfg)
+	get.Version = revision
+
+	r, err := get.Run(name)
+	if err == driver.ErrReleaseNotFound {
+		// the revision has been removed from the history, or the release
+		// uninstalled
+		return nil, errReleaseNotFound
+	}
+
+	return r, err
+}
+
+func setReleaseTestingAttributes(d *schema.ResourceData, r *release.Release) error {
+	if err := d.Set("revision", r.Version); err != nil {
+		return err
+	}
+
+	return d.Set("tests", flattenTestHooks(r.Hooks))
+}
+
+func flattenTestHooks(hooks []*release.Hook) []map[string]interface{} {
+	tests := []map[string]interface{}{}
+
+	for _, h := range hooks {
+		if !isTestHook(h) {
+			continue
+		}
+
+		tests = append(tests, map[string]interface{}{
+			"name":         h.Name,
+			"phase":        h.LastRun.Phase.String(),
+			"started_at":   formatTime(h.LastRun.StartedAt),
+			"completed_at": formatTime(h.LastRun.CompletedAt),
+		})
+	}
+
+	return tests
+}
+
+// formatTime formats a Helm timestamp as RFC 3339, or returns an empty string
+// if it is not set
+func formatTime(t helmtime.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
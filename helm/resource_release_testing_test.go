@@ -0,0 +1,154 @@
+package helm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+
+	"helm.sh/helm/v3/pkg/action"
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	helmtime "helm.sh/helm/v3/pkg/time"
+)
+
+func TestAccResourceReleaseTesting_basic(t *testing.T) {
+	name := randName("release-test")
+	namespace := createRandomNamespace(t)
+	defer deleteNamespace(t, namespace)
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckHelmReleaseDestroy(namespace),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccHelmReleaseTestingConfig(namespace, name, 1, false),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("helm_release_test.test", "revision", "1"),
+					resource.TestCheckResourceAttr("helm_release_test.test", "tests.#", "1"),
+					resource.TestCheckResourceAttr("helm_release_test.test", "tests.0.name", fmt.Sprintf("%s-test-chart-test-connection", name)),
+					resource.TestCheckResourceAttr("helm_release_test.test", "tests.0.phase", release.HookPhaseSucceeded.String()),
+					resource.TestCheckResourceAttrSet("helm_release_test.test", "tests.0.started_at"),
+					resource.TestCheckResourceAttrSet("helm_release_test.test", "tests.0.completed_at"),
+				),
+			},
+			{
+				Config: testAccHelmReleaseTestingConfig(namespace, name, 2, false),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("helm_release.test", "metadata.0.revision", "2"),
+					resource.TestCheckResourceAttr("helm_release_test.test", "revision", "1"),
+				),
+				// the upgrade is only seen by the next plan
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: testAccHelmReleaseTestingConfig(namespace, name, 2, false),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("helm_release_test.test", "revision", "2"),
+					resource.TestCheckResourceAttr("helm_release_test.test", "tests.0.phase", release.HookPhaseSucceeded.String()),
+				),
+			},
+			{
+				Config: testAccHelmReleaseTestingConfig(namespace, name, 3, true),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("helm_release.test", "metadata.0.revision", "3"),
+					resource.TestCheckResourceAttr("helm_release_test.test", "revision", "3"),
+				),
+			},
+		},
+	})
+}
+
+func TestFlattenTestHooks(t *testing.T) {
+	startedAt := helmtime.Date(2021, 6, 1, 10, 0, 0, 0, helmtime.Now().Location())
+	hooks := []*release.Hook{
+		{
+			Name:   "pre-install",
+			Events: []release.HookEvent{release.HookPreInstall},
+		},
+		{
+			Name:   "test-connection",
+			Events: []release.HookEvent{release.HookTest},
+			LastRun: release.HookExecution{
+				StartedAt: startedAt,
+				Phase:     release.HookPhaseRunning,
+			},
+		},
+	}
+
+	expected := []map[string]interface{}{{
+		"name":         "test-connection",
+		"phase":        "Running",
+		"started_at":   startedAt.UTC().Format("2006-01-02T15:04:05Z07:00"),
+		"completed_at": "",
+	}}
+
+	if tests := flattenTestHooks(hooks); !reflect.DeepEqual(tests, expected) {
+		t.Fatalf("error flattening test hooks, expected %v, got %v", expected, tests)
+	}
+}
+
+func TestGetReleaseRevision(t *testing.T) {
+	cfg := &action.Configuration{
+		Releases:   storage.Init(driver.NewMemory()),
+		KubeClient: &kubefake.PrintingKubeClient{Out: ioutil.Discard},
+	}
+	for i := 1; i <= 2; i++ {
+		r := &release.Release{Name: "test", Version: i, Info: &release.Info{Status: release.StatusDeployed}}
+		if err := cfg.Releases.Create(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := &Meta{}
+
+	r, err := getReleaseRevision(m, cfg, "test", 1)
+	if err != nil || r.Version != 1 {
+		t.Fatalf("expected revision 1, got %v, %v", r, err)
+	}
+
+	r, err = getReleaseRevision(m, cfg, "test", 0)
+	if err != nil || r.Version != 2 {
+		t.Fatalf("expected the latest revision, got %v, %v", r, err)
+	}
+
+	if _, err := getReleaseRevision(m, cfg, "test", 3); err != errReleaseNotFound {
+		t.Fatalf("expected a missing revision not to be found, got %v", err)
+	}
+}
+
+func testAccHelmReleaseTestingConfig(ns, name string, replicaCount int, triggers bool) string {
+	triggersBlock := ""
+	if triggers {
+		triggersBlock = `
+			triggers = {
+				revision = helm_release.test.metadata.0.revision
+			}`
+	}
+
+	return fmt.Sprintf(`
+		resource "helm_release" "test" {
+			name       = %q
+			namespace  = %q
+			repository = %q
+			chart      = "test-chart"
+			version    = "1.2.3"
+
+			set {
+				name  = "replicaCount"
+				value = %d
+			}
+		}
+
+		resource "helm_release_test" "test" {
+			name      = helm_release.test.name
+			namespace = helm_release.test.namespace
+			%s
+		}
+	`, name, ns, testRepositoryURL, replicaCount, triggersBlock)
+}
@@ -0,0 +1,361 @@
+package helm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pkg/errors"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/helmpath"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+func resourceRepository() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceRepositoryCreate,
+		ReadContext:   resourceRepositoryRead,
+		UpdateContext: resourceRepositoryUpdate,
+		DeleteContext: resourceRepositoryDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name of the repository, used to reference its charts as `<name>/<chart>`.",
+			},
+			"url": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "URL of the chart repository.",
+			},
+			"username": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Username for HTTP basic authentication",
+			},
+			"password": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				Description: "Password for HTTP basic authentication",
+			},
+			"key_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The repositories cert key file",
+			},
+			"cert_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The repositories cert file",
+			},
+			"ca_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The Repositories CA File",
+			},
+			"insecure_skip_tls_verify": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Skip TLS certificate checks for the repository",
+			},
+			"charts": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Charts available in the repository index.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The name of the chart.",
+						},
+						"versions": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "Available versions of the chart, newest first.",
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func resourceRepositoryCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	logID := fmt.Sprintf("[resourceRepositoryCreate: %s]", d.Get("name").(string))
+	debug("%s Started", logID)
+
+	m := meta.(*Meta)
+	name := d.Get("name").(string)
+
+	if err := saveRepository(m, repositoryEntry(d), false); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(name)
+	debug("%s Done", logID)
+
+	return resourceRepositoryRead(ctx, d, meta)
+}
+
+func resourceRepositoryRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	logID := fmt.Sprintf("[resourceRepositoryRead: %s]", d.Id())
+	debug("%s Started", logID)
+
+	m := meta.(*Meta)
+	name := d.Id()
+
+	var diags diag.Diagnostics
+
+	e, index, err := refreshRepository(m, name)
+	if err != nil && e == nil {
+		return diag.FromErr(err)
+	} else if err != nil {
+		// The repository can still be refreshed, and destroyed, while it
+		// cannot be reached, the charts of the state being kept
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Could not refresh the charts of the repository",
+			Detail:   err.Error(),
+		})
+	}
+
+	if e == nil {
+		d.SetId("")
+		return nil
+	}
+
+	if err := setRepositoryAttributes(d, e, index); err != nil {
+		return diag.FromErr(err)
+	}
+
+	debug("%s Done", logID)
+
+	return diags
+}
+
+func resourceRepositoryUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	m := meta.(*Meta)
+
+	if err := saveRepository(m, repositoryEntry(d), true); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return resourceRepositoryRead(ctx, d, meta)
+}
+
+func resourceRepositoryDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	m := meta.(*Meta)
+	name := d.Id()
+
+	m.Lock()
+	defer m.Unlock()
+
+	f, err := loadRepositoryFile(m)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	delete(m.repositoryIndexes, name)
+
+	if f.Remove(name) {
+		if err := f.WriteFile(m.Settings.RepositoryConfig, 0644); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	for _, cacheFile := range []string{helmpath.CacheIndexFile(name), helmpath.CacheChartsFile(name)} {
+		p := filepath.Join(m.Settings.RepositoryCache, cacheFile)
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return diag.FromErr(errors.Wrapf(err, "can't remove cache file %s", p))
+		}
+	}
+
+	d.SetId("")
+	return nil
+}
+
+func repositoryEntry(d resourceGetter) *repo.Entry {
+	return &repo.Entry{
+		Name:                  d.Get("name").(string),
+		URL:                   d.Get("url").(string),
+		Username:              d.Get("username").(string),
+		Password:              d.Get("password").(string),
+		CertFile:              d.Get("cert_file").(string),
+		KeyFile:               d.Get("key_file").(string),
+		CAFile:                d.Get("ca_file").(string),
+		InsecureSkipTLSverify: d.Get("insecure_skip_tls_verify").(bool),
+	}
+}
+
+// loadRepositoryFile reads the repositories file configured in the provider,
+// returning an empty one if it does not exist yet.
+func loadRepositoryFile(m *Meta) (*repo.File, error) {
+	f, err := repo.LoadFile(m.Settings.RepositoryConfig)
+	if err != nil {
+		if os.IsNotExist(errors.Cause(err)) {
+			return repo.NewFile(), nil
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+// saveRepository downloads the index of the repository into the cache and,
+// once it is known to be reachable, writes the entry to the repositories file.
+// The file is loaded and written under the lock, for the entries written
+// concurrently not to be lost. An existing entry of the same name is only
+// replaced if replace is set.
+func saveRepository(m *Meta, e *repo.Entry, replace bool) error {
+	m.Lock()
+	defer m.Unlock()
+
+	f, err := loadRepositoryFile(m)
+	if err != nil {
+		return err
+	}
+
+	if !replace && f.Has(e.Name) {
+		return fmt.Errorf("repository %q already exists in %s, import it instead", e.Name, m.Settings.RepositoryConfig)
+	}
+
+	// The entry may have changed since its index has been loaded
+	delete(m.repositoryIndexes, e.Name)
+	if _, err := loadRepositoryIndex(m, e); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(m.Settings.RepositoryConfig), os.ModePerm); err != nil {
+		return err
+	}
+
+	f.Update(e)
+	return f.WriteFile(m.Settings.RepositoryConfig, 0644)
+}
+
+func downloadRepositoryIndex(m *Meta, e *repo.Entry) (string, error) {
+	r, err := repo.NewChartRepository(e, getter.All(m.Settings))
+	if err != nil {
+		return "", err
+	}
+	r.CachePath = m.Settings.RepositoryCache
+
+	path, err := r.DownloadIndexFile()
+	if err != nil {
+		return "", errors.Wrapf(err, "looks like %q is not a valid chart repository or cannot be reached", e.URL)
+	}
+	return path, nil
+}
+
+// refreshRepository returns the entry of the repository from the
+// repositories file, downloading its index into the cache once per run for
+// the charts to be up to date. The entry is nil if the repository is not in
+// the file. The entry is still returned along with the error if the index
+// cannot be downloaded.
+func refreshRepository(m *Meta, name string) (*repo.Entry, *repo.IndexFile, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	f, err := loadRepositoryFile(m)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	e := f.Get(name)
+	if e == nil {
+		return nil, nil, nil
+	}
+
+	index, err := loadRepositoryIndex(m, e)
+	if err != nil {
+		return e, nil, err
+	}
+
+	return e, index, nil
+}
+
+// loadRepositoryIndex returns the index of the repository, downloading it
+// into the repository cache at most once per run. It must be called with the
+// lock held.
+func loadRepositoryIndex(m *Meta, e *repo.Entry) (*repo.IndexFile, error) {
+	if index, ok := m.repositoryIndexes[e.Name]; ok {
+		return index, nil
+	}
+
+	path, err := downloadRepositoryIndex(m, e)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := repo.LoadIndexFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.repositoryIndexes == nil {
+		m.repositoryIndexes = map[string]*repo.IndexFile{}
+	}
+	m.repositoryIndexes[e.Name] = index
+
+	return index, nil
+}
+
+func setRepositoryAttributes(d *schema.ResourceData, e *repo.Entry, index *repo.IndexFile) error {
+	attributes := map[string]interface{}{
+		"name":                     e.Name,
+		"url":                      e.URL,
+		"username":                 e.Username,
+		"password":                 e.Password,
+		"cert_file":                e.CertFile,
+		"key_file":                 e.KeyFile,
+		"ca_file":                  e.CAFile,
+		"insecure_skip_tls_verify": e.InsecureSkipTLSverify,
+	}
+
+	// The charts are kept as they are if the index could not be read
+	if index != nil {
+		attributes["charts"] = flattenRepositoryCharts(index)
+	}
+
+	for k, v := range attributes {
+		if err := d.Set(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func flattenRepositoryCharts(index *repo.IndexFile) []map[string]interface{} {
+	index.SortEntries()
+
+	names := make([]string, 0, len(index.Entries))
+	for name := range index.Entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	charts := make([]map[string]interface{}, 0, len(names))
+	for _, name := range names {
+		versions := []string{}
+		for _, cv := range index.Entries[name] {
+			versions = append(versions, cv.Version)
+		}
+
+		charts = append(charts, map[string]interface{}{
+			"name":     name,
+			"versions": versions,
+		})
+	}
+	return charts
+}
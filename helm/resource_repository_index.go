@@ -0,0 +1,265 @@
+package helm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"helm.sh/helm/v3/pkg/provenance"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+func resourceRepositoryIndex() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceRepositoryIndexCreate,
+		ReadContext:   resourceRepositoryIndexRead,
+		UpdateContext: resourceRepositoryIndexUpdate,
+		DeleteContext: resourceRepositoryIndexDelete,
+		CustomizeDiff: resourceRepositoryIndexDiff,
+		Schema: map[string]*schema.Schema{
+			"directory": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Directory of the chart archives to index. The index.yaml is written into it.",
+			},
+			"url": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "URL of the chart repository, used as the base of the URLs of the charts.",
+			},
+			"merge": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Path of an existing index to merge the generated index into.",
+			},
+			"archives_digest": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The SHA256 digest of the names and digests of the indexed archives, used to index them again when they change.",
+			},
+			"merge_digest": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The SHA256 digest of the index to merge, used to index the archives again when it changes.",
+			},
+			"index_path": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Path of the generated index.yaml.",
+			},
+			"charts": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The charts in the index.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The name of the chart.",
+						},
+						"versions": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "The versions of the chart, newest first.",
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func resourceRepositoryIndexCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	logID := fmt.Sprintf("[resourceRepositoryIndexCreate: %s]", d.Get("directory").(string))
+	debug("%s Started", logID)
+
+	path, err := indexRepository(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(path)
+
+	debug("%s Done", logID)
+
+	return resourceRepositoryIndexRead(ctx, d, meta)
+}
+
+func resourceRepositoryIndexRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if _, err := os.Stat(d.Id()); os.IsNotExist(err) {
+		debug("repository index %s is gone, indexing again", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	index, err := repo.LoadIndexFile(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := d.Set("index_path", d.Id()); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := d.Set("charts", flattenRepositoryCharts(index)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+func resourceRepositoryIndexUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if _, err := indexRepository(d); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return resourceRepositoryIndexRead(ctx, d, meta)
+}
+
+func resourceRepositoryIndexDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if err := os.Remove(d.Id()); err != nil && !os.IsNotExist(err) {
+		return diag.FromErr(err)
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// resourceRepositoryIndexDiff indexes the archives again when they are added,
+// removed or changed, or when the index to merge changes
+func resourceRepositoryIndexDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || !d.NewValueKnown("directory") || !d.NewValueKnown("merge") {
+		return nil
+	}
+
+	digest, err := chartArchivesDigest(d.Get("directory").(string))
+	if err != nil {
+		return err
+	}
+
+	mergeDigest, err := mergeIndexDigest(d.Get("merge").(string))
+	if err != nil {
+		return err
+	}
+
+	changed := false
+
+	if old, _ := d.GetChange("archives_digest"); old.(string) != digest {
+		if err := d.SetNew("archives_digest", digest); err != nil {
+			return err
+		}
+		changed = true
+	}
+
+	if old, _ := d.GetChange("merge_digest"); old.(string) != mergeDigest {
+		if err := d.SetNew("merge_digest", mergeDigest); err != nil {
+			return err
+		}
+		changed = true
+	}
+
+	if changed {
+		return d.SetNewComputed("charts")
+	}
+	return nil
+}
+
+// indexRepository writes the index of the directory and records the digests
+// of the archives it indexed and of the index it merged
+func indexRepository(d *schema.ResourceData) (string, error) {
+	dir := d.Get("directory").(string)
+
+	digest, err := chartArchivesDigest(dir)
+	if err != nil {
+		return "", err
+	}
+
+	merge := d.Get("merge").(string)
+
+	mergeDigest, err := mergeIndexDigest(merge)
+	if err != nil {
+		return "", err
+	}
+
+	path, err := writeRepositoryIndex(dir, d.Get("url").(string), merge)
+	if err != nil {
+		return "", err
+	}
+
+	if err := d.Set("merge_digest", mergeDigest); err != nil {
+		return "", err
+	}
+
+	return path, d.Set("archives_digest", digest)
+}
+
+// mergeIndexDigest returns the digest of the index to merge, or an empty
+// string if there is none
+func mergeIndexDigest(merge string) (string, error) {
+	if merge == "" {
+		return "", nil
+	}
+	return provenance.DigestFile(merge)
+}
+
+// writeRepositoryIndex indexes the chart archives of the directory and writes
+// the index.yaml into it, like `helm repo index` does
+func writeRepositoryIndex(dir, url, merge string) (string, error) {
+	index, err := repo.IndexDirectory(dir, url)
+	if err != nil {
+		return "", err
+	}
+
+	if merge != "" {
+		existing, err := repo.LoadIndexFile(merge)
+		if err != nil {
+			return "", err
+		}
+		index.Merge(existing)
+	}
+
+	index.SortEntries()
+
+	path := filepath.Join(dir, "index.yaml")
+	return path, index.WriteFile(path, 0644)
+}
+
+// chartArchivesDigest returns the digest of the names and digests of the
+// chart archives found by repo.IndexDirectory
+func chartArchivesDigest(dir string) (string, error) {
+	archives, err := filepath.Glob(filepath.Join(dir, "*.tgz"))
+	if err != nil {
+		return "", err
+	}
+
+	moreArchives, err := filepath.Glob(filepath.Join(dir, "**/*.tgz"))
+	if err != nil {
+		return "", err
+	}
+	archives = append(archives, moreArchives...)
+	sort.Strings(archives)
+
+	h := sha256.New()
+	for _, arch := range archives {
+		digest, err := provenance.DigestFile(arch)
+		if err != nil {
+			return "", err
+		}
+
+		name, err := filepath.Rel(dir, arch)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s\x00%s\n", name, digest)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,165 @@
+package helm
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+func TestAccResourceRepositoryIndex_basic(t *testing.T) {
+	dir := t.TempDir()
+	saveTestChart(t, "test-chart", "1.2.3", dir)
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{{
+			Config: testAccHelmRepositoryIndexConfig(testResourceName, dir),
+			Check: resource.ComposeAggregateTestCheckFunc(
+				resource.TestCheckResourceAttr("helm_repository_index.test", "index_path", filepath.Join(dir, "index.yaml")),
+				resource.TestCheckResourceAttr("helm_repository_index.test", "charts.#", "1"),
+				resource.TestCheckResourceAttr("helm_repository_index.test", "charts.0.versions.#", "1"),
+			),
+		}, {
+			PreConfig: func() { saveTestChart(t, "test-chart", "1.2.4", dir) },
+			Config:    testAccHelmRepositoryIndexConfig(testResourceName, dir),
+			Check: resource.ComposeAggregateTestCheckFunc(
+				resource.TestCheckResourceAttr("helm_repository_index.test", "charts.0.versions.#", "2"),
+				resource.TestCheckResourceAttr("helm_repository_index.test", "charts.0.versions.0", "1.2.4"),
+			),
+		}},
+	})
+}
+
+func TestResourceRepositoryIndex(t *testing.T) {
+	dir := t.TempDir()
+	saveTestChart(t, "test-chart", "1.2.3", dir)
+
+	d := schema.TestResourceDataRaw(t, resourceRepositoryIndex().Schema, map[string]interface{}{
+		"directory": dir,
+		"url":       "https://charts.example.com",
+	})
+
+	if diags := resourceRepositoryIndexCreate(context.Background(), d, nil); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	index, err := repo.LoadIndexFile(filepath.Join(dir, "index.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cv, err := index.Get("test-chart", "1.2.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cv.URLs[0] != "https://charts.example.com/test-chart-1.2.3.tgz" {
+		t.Errorf("unexpected chart URL %q", cv.URLs[0])
+	}
+
+	if d.Get("charts.0.name") != "test-chart" {
+		t.Errorf("unexpected charts %v", d.Get("charts"))
+	}
+
+	digest := d.Get("archives_digest").(string)
+
+	// Rewriting an archive changes its digest
+	saveTestChart(t, "test-chart", "1.2.3", dir, "changed")
+
+	changed, err := chartArchivesDigest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if changed == digest {
+		t.Error("expected the digest of the archives to change")
+	}
+}
+
+func TestWriteRepositoryIndexMerge(t *testing.T) {
+	existing := repo.NewIndexFile()
+	if err := existing.MustAdd(&chart.Metadata{APIVersion: "v2", Name: "other-chart", Version: "0.1.0"}, "other-chart-0.1.0.tgz", "https://charts.example.com", "sha256:abc"); err != nil {
+		t.Fatal(err)
+	}
+
+	merge := filepath.Join(t.TempDir(), "index.yaml")
+	if err := existing.WriteFile(merge, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	saveTestChart(t, "test-chart", "1.2.3", dir)
+
+	path, err := writeRepositoryIndex(dir, "", merge)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := repo.LoadIndexFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"test-chart", "other-chart"} {
+		if !index.Has(name, "") {
+			t.Errorf("expected %s in the merged index", name)
+		}
+	}
+
+	// Changing the index to merge changes its digest
+	digest, err := mergeIndexDigest(merge)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := existing.MustAdd(&chart.Metadata{APIVersion: "v2", Name: "other-chart", Version: "0.2.0"}, "other-chart-0.2.0.tgz", "https://charts.example.com", "sha256:def"); err != nil {
+		t.Fatal(err)
+	}
+	if err := existing.WriteFile(merge, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := mergeIndexDigest(merge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed == digest {
+		t.Error("expected the digest of the index to merge to change")
+	}
+}
+
+// saveTestChart packages the test chart of the given name with the version
+// into dir, with an optional description to change its content
+func saveTestChart(t *testing.T, name, version, dir string, description ...string) {
+	c, err := loader.Load(filepath.Join(testChartsPath, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Metadata.Version = version
+	if len(description) > 0 {
+		c.Metadata.Description = description[0]
+	}
+
+	if _, err := chartutil.Save(c, dir); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testAccHelmRepositoryIndexConfig(resource, dir string) string {
+	return fmt.Sprintf(`
+		resource "helm_repository_index" "%s" {
+			directory = %q
+		}
+	`, resource, dir)
+}
@@ -0,0 +1,226 @@
+package helm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+func TestAccResourceRepository_basic(t *testing.T) {
+	name := randName("repository")
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckHelmRepositoryDestroy(name),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccHelmRepositoryConfigBasic(testResourceName, name, testRepositoryURL),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("helm_repository.test", "name", name),
+					resource.TestCheckResourceAttr("helm_repository.test", "url", testRepositoryURL),
+					resource.TestCheckResourceAttrSet("helm_repository.test", "charts.#"),
+				),
+			},
+			{
+				ResourceName:      "helm_repository.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccResourceRepository_release(t *testing.T) {
+	repositoryName := randName("repository")
+	name := randName("repository-release")
+	namespace := createRandomNamespace(t)
+	defer deleteNamespace(t, namespace)
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckHelmReleaseDestroy(namespace),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccHelmRepositoryConfigRelease(repositoryName, testRepositoryURL, namespace, name),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("helm_release.test", "metadata.0.chart", "test-chart"),
+					resource.TestCheckResourceAttr("helm_release.test", "metadata.0.version", "1.2.3"),
+				),
+			},
+		},
+	})
+}
+
+func TestFlattenRepositoryCharts(t *testing.T) {
+	index := repo.NewIndexFile()
+	index.Add(&chart.Metadata{APIVersion: chart.APIVersionV2, Name: "foo", Version: "1.0.0"}, "foo-1.0.0.tgz", "http://example.com", "")
+	index.Add(&chart.Metadata{APIVersion: chart.APIVersionV2, Name: "foo", Version: "1.1.0"}, "foo-1.1.0.tgz", "http://example.com", "")
+	index.Add(&chart.Metadata{APIVersion: chart.APIVersionV2, Name: "bar", Version: "0.1.0"}, "bar-0.1.0.tgz", "http://example.com", "")
+
+	expected := []map[string]interface{}{
+		{"name": "bar", "versions": []string{"0.1.0"}},
+		{"name": "foo", "versions": []string{"1.1.0", "1.0.0"}},
+	}
+
+	if charts := flattenRepositoryCharts(index); !reflect.DeepEqual(charts, expected) {
+		t.Fatalf("error flattening charts, expected %v, got %v", expected, charts)
+	}
+}
+
+func TestSaveRepositoryConcurrently(t *testing.T) {
+	index := testIndex
+	var mu sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprint(w, index)
+	}))
+	defer server.Close()
+
+	m := newTestMeta(t)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- saveRepository(m, &repo.Entry{Name: fmt.Sprintf("repo-%d", i), URL: server.URL}, false)
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := loadRepositoryFile(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Repositories) != 10 {
+		t.Fatalf("expected 10 repositories, got %d", len(f.Repositories))
+	}
+
+	if err := saveRepository(m, &repo.Entry{Name: "repo-0", URL: server.URL}, false); err == nil {
+		t.Fatal("expected an error for an existing repository")
+	}
+
+	// the index is downloaded once per run, and refreshed on read in the
+	// next one
+	mu.Lock()
+	index = `apiVersion: v1
+entries:
+  other:
+  - name: other
+    version: 0.1.0
+    urls:
+    - other-0.1.0.tgz
+`
+	mu.Unlock()
+
+	_, cached, err := refreshRepository(m, "repo-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached.Has("other", "0.1.0") {
+		t.Fatalf("expected the index to be downloaded once per run, got %v", cached.Entries)
+	}
+
+	m = &Meta{Settings: m.Settings}
+	_, refreshed, err := refreshRepository(m, "repo-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !refreshed.Has("other", "0.1.0") {
+		t.Fatalf("expected the index to be refreshed, got %v", refreshed.Entries)
+	}
+
+	if e, _, err := refreshRepository(m, "missing"); err != nil || e != nil {
+		t.Fatalf("expected no entry for a missing repository, got %v, %v", e, err)
+	}
+
+	// a repository that cannot be reached is refreshed with a warning, and
+	// can be destroyed
+	server.Close()
+
+	d := resourceRepository().Data(nil)
+	d.SetId("repo-0")
+	d.Set("charts", flattenRepositoryCharts(refreshed))
+
+	m = &Meta{Settings: m.Settings}
+	diags := resourceRepositoryRead(context.Background(), d, m)
+	if diags.HasError() || len(diags) != 1 || diags[0].Severity != diag.Warning {
+		t.Fatalf("expected a warning, got %v", diags)
+	}
+	if d.Id() != "repo-0" || d.Get("charts.0.name") != "other" {
+		t.Fatalf("expected the state to be kept, got %q %v", d.Id(), d.Get("charts"))
+	}
+
+	if diags := resourceRepositoryDelete(context.Background(), d, m); diags.HasError() {
+		t.Fatal(diags)
+	}
+}
+
+func testAccCheckHelmRepositoryDestroy(name string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		m := testAccProvider.Meta()
+		if m == nil {
+			return fmt.Errorf("provider not properly initialized")
+		}
+
+		f, err := loadRepositoryFile(m.(*Meta))
+		if err != nil {
+			return err
+		}
+
+		if f.Has(name) {
+			return fmt.Errorf("found %q repository", name)
+		}
+
+		return nil
+	}
+}
+
+func testAccHelmRepositoryConfigBasic(resource, name, url string) string {
+	return fmt.Sprintf(`
+		resource "helm_repository" %q {
+			name = %q
+			url  = %q
+		}
+	`, resource, name, url)
+}
+
+func testAccHelmRepositoryConfigRelease(repositoryName, url, ns, name string) string {
+	return fmt.Sprintf(`
+		resource "helm_repository" "test" {
+			name = %q
+			url  = %q
+		}
+
+		resource "helm_release" "test" {
+			name       = %q
+			namespace  = %q
+			repository = helm_repository.test.name
+			chart      = "test-chart"
+			version    = "1.2.3"
+		}
+	`, repositoryName, url, name, ns)
+}
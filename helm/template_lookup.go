@@ -0,0 +1,421 @@
+package helm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	lookupModeDisabled = "disabled"
+	lookupModeFixtures = "fixtures"
+	lookupModeCluster  = "cluster"
+)
+
+// templateLookupMode returns how the lookup function finds objects while
+// rendering the chart. It defaults to the fixtures if any are given.
+func templateLookupMode(d resourceGetter) (string, error) {
+	mode := d.Get("lookup_mode").(string)
+	hasObjects := len(d.Get("lookup_objects").([]interface{})) > 0
+
+	switch {
+	case mode == "" && hasObjects:
+		return lookupModeFixtures, nil
+	case mode == "":
+		return lookupModeDisabled, nil
+	case mode != lookupModeFixtures && hasObjects:
+		return "", fmt.Errorf("lookup_objects can only be used with the %q lookup_mode, got %q", lookupModeFixtures, mode)
+	}
+
+	return mode, nil
+}
+
+// lookupConfiguration returns the configuration to render the chart with when
+// lookups are enabled. Helm only looks up objects when the release is actually
+// installed, so it is installed with a client which does not apply anything
+// and an in-memory storage instead. The objects are looked up from the
+// fixtures or from the cluster, depending on the mode.
+func lookupConfiguration(d resourceGetter, m *Meta, namespace, mode string, cfg *action.Configuration) (*action.Configuration, error) {
+	getter := cfg.RESTClientGetter
+
+	switch mode {
+	case lookupModeCluster:
+		if getter == nil {
+			clusterConfig, err := m.GetHelmConfiguration(namespace)
+			if err != nil {
+				return nil, err
+			}
+			getter = clusterConfig.RESTClientGetter
+		}
+	case lookupModeFixtures:
+		objects, err := loadLookupObjects(d.Get("lookup_objects").([]interface{}))
+		if err != nil {
+			return nil, err
+		}
+		getter = &lookupFixtures{cluster: getter, objects: objects}
+	}
+
+	mem := driver.NewMemory()
+	mem.SetNamespace(namespace)
+
+	return &action.Configuration{
+		RESTClientGetter: getter,
+		Releases:         storage.Init(mem),
+		KubeClient:       &kubefake.PrintingKubeClient{Out: ioutil.Discard},
+		Capabilities:     cfg.Capabilities,
+		Log:              debug,
+	}, nil
+}
+
+// renderWithLookups renders the chart by installing, or upgrading, the release
+// with a configuration returned by lookupConfiguration
+func renderWithLookups(cfg *action.Configuration, client *action.Install, c *chart.Chart, values map[string]interface{}) (*release.Release, error) {
+	if !client.IsUpgrade {
+		install := *client
+		install.DryRun = false
+		install.SkipCRDs = true
+		return install.Run(c, values)
+	}
+
+	// Helm only sets .Release.IsUpgrade for dry runs of the install action, so
+	// the release is upgraded from a placeholder revision instead
+	placeholder := &release.Release{
+		Name:      client.ReleaseName,
+		Namespace: client.Namespace,
+		Chart:     c,
+		Config:    map[string]interface{}{},
+		Info:      &release.Info{Status: release.StatusDeployed},
+	}
+	if err := cfg.Releases.Create(placeholder); err != nil {
+		return nil, err
+	}
+
+	upgrade := action.NewUpgrade(cfg)
+	upgrade.Namespace = client.Namespace
+	upgrade.Timeout = client.Timeout
+	upgrade.Wait = client.Wait
+	upgrade.DisableHooks = client.DisableHooks
+	upgrade.SubNotes = client.SubNotes
+	upgrade.Description = client.Description
+	upgrade.PostRenderer = client.PostRenderer
+	upgrade.DisableOpenAPIValidation = client.DisableOpenAPIValidation
+	// the values have already been merged with the ones of the release
+	upgrade.ResetValues = true
+
+	rel, err := upgrade.Run(client.ReleaseName, c, values)
+	if err != nil {
+		return nil, err
+	}
+
+	// unlike the install action, the upgrade action never renders the CRDs
+	if client.IncludeCRDs {
+		var crds bytes.Buffer
+		for _, crd := range c.CRDObjects() {
+			fmt.Fprintf(&crds, "---\n# Source: %s\n%s\n", crd.Name, string(crd.File.Data))
+		}
+		rel.Manifest = crds.String() + rel.Manifest
+	}
+
+	return rel, nil
+}
+
+// loadLookupObjects parses the lookup_objects. Each of them is either YAML or
+// JSON documents, or the path of a directory whose .yaml, .yml and .json files
+// hold the objects.
+func loadLookupObjects(sources []interface{}) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+
+	for _, raw := range sources {
+		source := raw.(string)
+
+		if !strings.Contains(source, "\n") {
+			if fi, err := os.Stat(source); err == nil && fi.IsDir() {
+				dirObjects, err := loadLookupObjectsDir(source)
+				if err != nil {
+					return nil, err
+				}
+				objects = append(objects, dirObjects...)
+				continue
+			}
+		}
+
+		parsed, err := parseLookupObjects(source, "lookup_objects")
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, parsed...)
+	}
+
+	return objects, nil
+}
+
+func loadLookupObjectsDir(dir string) ([]*unstructured.Unstructured, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []*unstructured.Unstructured
+
+	for _, fi := range files {
+		switch filepath.Ext(fi.Name()) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+
+		path := filepath.Join(dir, fi.Name())
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		parsed, err := parseLookupObjects(string(data), path)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, parsed...)
+	}
+
+	return objects, nil
+}
+
+// parseLookupObjects parses the YAML or JSON documents of content, expanding
+// lists into their items
+func parseLookupObjects(content, origin string) ([]*unstructured.Unstructured, error) {
+	docs := releaseutil.SplitManifests(content)
+
+	keys := make([]string, 0, len(docs))
+	for k := range docs {
+		keys = append(keys, k)
+	}
+	sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+
+	var objects []*unstructured.Unstructured
+
+	for _, k := range keys {
+		data, err := yaml.YAMLToJSON([]byte(docs[k]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse object in %s: %s", origin, err)
+		}
+
+		// documents holding only comments
+		if string(data) == "null" {
+			continue
+		}
+
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(data); err != nil {
+			return nil, fmt.Errorf("failed to parse object in %s: %s", origin, err)
+		}
+
+		if !obj.IsList() {
+			objects = append(objects, obj)
+			continue
+		}
+
+		err = obj.EachListItem(func(item runtime.Object) error {
+			objects = append(objects, item.(*unstructured.Unstructured))
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse list in %s: %s", origin, err)
+		}
+	}
+
+	return objects, nil
+}
+
+// lookupFixtures serves the objects to the lookup function, by acting as the
+// Kubernetes API server for the REST config it provides. The discovery and
+// the mapping of resources are delegated to the cluster, if any, for Helm to
+// get the capabilities when validating.
+type lookupFixtures struct {
+	cluster action.RESTClientGetter
+	objects []*unstructured.Unstructured
+}
+
+func (f *lookupFixtures) ToRESTConfig() (*rest.Config, error) {
+	return &rest.Config{
+		Host:      "http://lookup-fixtures",
+		Transport: f,
+	}, nil
+}
+
+func (f *lookupFixtures) ToDiscoveryClient() (discovery.CachedDiscoveryInterface, error) {
+	if f.cluster == nil {
+		return nil, fmt.Errorf("discovery is not available when rendering without a cluster")
+	}
+	return f.cluster.ToDiscoveryClient()
+}
+
+func (f *lookupFixtures) ToRESTMapper() (meta.RESTMapper, error) {
+	if f.cluster == nil {
+		return nil, fmt.Errorf("REST mapping is not available when rendering without a cluster")
+	}
+	return f.cluster.ToRESTMapper()
+}
+
+// RoundTrip answers the requests of the discovery and dynamic clients used by
+// the lookup function
+func (f *lookupFixtures) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		status := apierrors.NewMethodNotSupported(k8sschema.GroupResource{}, req.Method).ErrStatus
+		return lookupResponse(req, http.StatusMethodNotAllowed, status)
+	}
+
+	gv, segments, ok := splitLookupPath(req.URL.Path)
+	if !ok {
+		status := apierrors.NewNotFound(k8sschema.GroupResource{}, req.URL.Path).ErrStatus
+		return lookupResponse(req, http.StatusNotFound, status)
+	}
+
+	if len(segments) == 0 {
+		return lookupResponse(req, http.StatusOK, f.resourceList(gv))
+	}
+
+	var namespace, resource, name string
+
+	switch {
+	case len(segments) == 1:
+		resource = segments[0]
+	case len(segments) == 2:
+		resource, name = segments[0], segments[1]
+	case len(segments) == 3 && segments[0] == "namespaces":
+		namespace, resource = segments[1], segments[2]
+	case len(segments) == 4 && segments[0] == "namespaces":
+		namespace, resource, name = segments[1], segments[2], segments[3]
+	default:
+		status := apierrors.NewNotFound(k8sschema.GroupResource{}, req.URL.Path).ErrStatus
+		return lookupResponse(req, http.StatusNotFound, status)
+	}
+
+	kind := ""
+	items := []interface{}{}
+
+	for _, obj := range f.objects {
+		gvk := obj.GroupVersionKind()
+		if gvk.GroupVersion() != gv || lookupResource(gvk) != resource {
+			continue
+		}
+		kind = gvk.Kind
+
+		if namespace != "" && obj.GetNamespace() != namespace {
+			continue
+		}
+
+		if name == "" {
+			items = append(items, obj.Object)
+			continue
+		}
+
+		if obj.GetName() == name {
+			return lookupResponse(req, http.StatusOK, obj.Object)
+		}
+	}
+
+	if name != "" {
+		status := apierrors.NewNotFound(gv.WithResource(resource).GroupResource(), name).ErrStatus
+		return lookupResponse(req, http.StatusNotFound, status)
+	}
+
+	return lookupResponse(req, http.StatusOK, map[string]interface{}{
+		"apiVersion": gv.String(),
+		"kind":       kind + "List",
+		"metadata":   map[string]interface{}{},
+		"items":      items,
+	})
+}
+
+// resourceList returns the discovery document of the group version, listing
+// the resources of the objects
+func (f *lookupFixtures) resourceList(gv k8sschema.GroupVersion) *metav1.APIResourceList {
+	list := &metav1.APIResourceList{
+		TypeMeta:     metav1.TypeMeta{Kind: "APIResourceList", APIVersion: "v1"},
+		GroupVersion: gv.String(),
+		APIResources: []metav1.APIResource{},
+	}
+
+	index := map[string]int{}
+
+	for _, obj := range f.objects {
+		gvk := obj.GroupVersionKind()
+		if gvk.GroupVersion() != gv {
+			continue
+		}
+
+		// a kind is namespaced if any of its objects is
+		if i, ok := index[gvk.Kind]; ok {
+			list.APIResources[i].Namespaced = list.APIResources[i].Namespaced || obj.GetNamespace() != ""
+			continue
+		}
+
+		index[gvk.Kind] = len(list.APIResources)
+		list.APIResources = append(list.APIResources, metav1.APIResource{
+			Name:         lookupResource(gvk),
+			SingularName: strings.ToLower(gvk.Kind),
+			Namespaced:   obj.GetNamespace() != "",
+			Kind:         gvk.Kind,
+			Verbs:        metav1.Verbs{"get", "list"},
+		})
+	}
+
+	return list
+}
+
+// splitLookupPath splits the path of a request into the group version and the
+// remaining segments, e.g. /apis/apps/v1/namespaces/default/deployments
+func splitLookupPath(path string) (k8sschema.GroupVersion, []string, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	switch {
+	case len(segments) >= 2 && segments[0] == "api":
+		return k8sschema.GroupVersion{Version: segments[1]}, segments[2:], true
+	case len(segments) >= 3 && segments[0] == "apis":
+		return k8sschema.GroupVersion{Group: segments[1], Version: segments[2]}, segments[3:], true
+	}
+
+	return k8sschema.GroupVersion{}, nil, false
+}
+
+func lookupResource(gvk k8sschema.GroupVersionKind) string {
+	plural, _ := meta.UnsafeGuessKindToResource(gvk)
+	return plural.Resource
+}
+
+func lookupResponse(req *http.Request, code int, body interface{}) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		StatusCode: code,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(bytes.NewReader(data)),
+		Request:    req,
+	}, nil
+}
@@ -0,0 +1,118 @@
+package helm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+)
+
+func TestTemplateLookupMode(t *testing.T) {
+	cases := []struct {
+		attributes map[string]interface{}
+		expected   string
+		err        bool
+	}{
+		{map[string]interface{}{}, lookupModeDisabled, false},
+		{map[string]interface{}{"lookup_objects": []interface{}{"./testdata/lookup"}}, lookupModeFixtures, false},
+		{map[string]interface{}{"lookup_mode": "cluster"}, lookupModeCluster, false},
+		{map[string]interface{}{"lookup_mode": "cluster", "lookup_objects": []interface{}{"./testdata/lookup"}}, "", true},
+	}
+
+	for _, tc := range cases {
+		d := schema.TestResourceDataRaw(t, dataTemplate().Schema, tc.attributes)
+
+		mode, err := templateLookupMode(d)
+		if tc.err != (err != nil) {
+			t.Errorf("unexpected error for %v: %v", tc.attributes, err)
+		}
+		if mode != tc.expected {
+			t.Errorf("expected mode %q for %v, got %q", tc.expected, tc.attributes, mode)
+		}
+	}
+}
+
+func TestLookupFixtures(t *testing.T) {
+	objects, err := loadLookupObjects([]interface{}{
+		"./testdata/lookup",
+		`{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web", "namespace": "default"}}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(objects) != 5 {
+		t.Fatalf("expected 5 objects, got %d", len(objects))
+	}
+
+	f := &lookupFixtures{objects: objects}
+	config, err := f.ToRESTConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dc, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resources, err := dc.ServerResourcesForGroupVersion("v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	namespaced := map[string]bool{}
+	for _, r := range resources.APIResources {
+		namespaced[r.Name] = r.Namespaced
+	}
+	if len(namespaced) != 2 || !namespaced["configmaps"] || namespaced["namespaces"] {
+		t.Errorf("unexpected resources %v", resources.APIResources)
+	}
+
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	configMaps := client.Resource(k8sschema.GroupVersionResource{Version: "v1", Resource: "configmaps"})
+
+	list, err := configMaps.Namespace("default").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 2 {
+		t.Errorf("expected 2 config maps in default, got %d", len(list.Items))
+	}
+
+	list, err = configMaps.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 3 {
+		t.Errorf("expected 3 config maps in all namespaces, got %d", len(list.Items))
+	}
+
+	deployment, err := client.Resource(k8sschema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}).
+		Namespace("default").Get(ctx, "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deployment.GetKind() != "Deployment" {
+		t.Errorf("unexpected deployment %v", deployment)
+	}
+
+	_, err = configMaps.Namespace("other").Get(ctx, "first", metav1.GetOptions{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+
+	if _, err := f.ToDiscoveryClient(); err == nil {
+		t.Error("expected an error for discovery without a cluster")
+	}
+}
@@ -0,0 +1,322 @@
+package helm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// valuesFrom holds what has been read from the ConfigMaps and Secrets of
+// values_from
+type valuesFrom struct {
+	// values merged in the order of values_from
+	values map[string]interface{}
+	// hashes of the data, by reference
+	hashes map[string]interface{}
+	// values read from Secrets, to be redacted from the manifest
+	secrets []secretValue
+	// paths of the values read from Secrets, to be cloaked
+	secretPaths [][]string
+	// set when the ConfigMaps and Secrets could not be read, and the paths
+	// cloaked in the state are cloaked again instead
+	unavailable bool
+}
+
+func newValuesFrom() *valuesFrom {
+	return &valuesFrom{
+		values: map[string]interface{}{},
+		hashes: map[string]interface{}{},
+	}
+}
+
+// getValuesFrom reads the ConfigMaps and Secrets of values_from with the
+// Kubernetes client of the Helm configuration. It is done once per operation,
+// the result being passed to the functions needing it.
+func getValuesFrom(ctx context.Context, d resourceGetter, m *Meta) (*valuesFrom, error) {
+	refs := d.Get("values_from").([]interface{})
+	namespace := d.Get("namespace").(string)
+
+	if len(refs) == 0 {
+		return newValuesFrom(), nil
+	}
+
+	actionConfig, err := m.GetHelmConfiguration(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := actionConfig.KubernetesClientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	return readValuesFrom(ctx, client, refs, namespace)
+}
+
+func readValuesFrom(ctx context.Context, client kubernetes.Interface, refs []interface{}, namespace string) (*valuesFrom, error) {
+	result := newValuesFrom()
+
+	for _, raw := range refs {
+		ref := raw.(map[string]interface{})
+		kind := ref["kind"].(string)
+		name := ref["name"].(string)
+		key := ref["key"].(string)
+		targetPath := ref["target_path"].(string)
+
+		ns := ref["namespace"].(string)
+		if ns == "" {
+			ns = namespace
+		}
+
+		id := fmt.Sprintf("%s/%s/%s/%s", kind, ns, name, key)
+
+		var data []byte
+
+		switch kind {
+		case "ConfigMap":
+			cm, err := client.CoreV1().ConfigMaps(ns).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to read values_from %s: %s", id, err)
+			}
+
+			if v, ok := cm.Data[key]; ok {
+				data = []byte(v)
+			} else if v, ok := cm.BinaryData[key]; ok {
+				data = v
+			} else {
+				return nil, fmt.Errorf("values_from %s: key %q not found", id, key)
+			}
+		case "Secret":
+			secret, err := client.CoreV1().Secrets(ns).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to read values_from %s: %s", id, err)
+			}
+
+			v, ok := secret.Data[key]
+			if !ok {
+				return nil, fmt.Errorf("values_from %s: key %q not found", id, key)
+			}
+			data = v
+		default:
+			return nil, fmt.Errorf("unexpected values_from kind: %s", kind)
+		}
+
+		sum := sha256.Sum256(data)
+		result.hashes[id] = hex.EncodeToString(sum[:])
+
+		if targetPath != "" {
+			if err := setRawValue(result.values, targetPath, string(data)); err != nil {
+				return nil, fmt.Errorf("failed parsing target_path %q of values_from %s, %s", targetPath, id, err)
+			}
+
+			if kind == "Secret" {
+				path := strings.Split(targetPath, ".")
+				result.secrets = append(result.secrets, secretValue{path: path, value: string(data)})
+				result.secretPaths = append(result.secretPaths, path)
+			}
+			continue
+		}
+
+		values := map[string]interface{}{}
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return nil, fmt.Errorf("failed to parse values_from %s: %s", id, err)
+		}
+
+		result.values = mergeMaps(result.values, values)
+
+		if kind == "Secret" {
+			result.secrets = append(result.secrets, leafValues(values)...)
+			result.secretPaths = append(result.secretPaths, leafPaths(values, nil)...)
+		}
+	}
+
+	return result, nil
+}
+
+// getReleaseValues returns the values of the release, the values of
+// values_from being merged before the other ones
+func getReleaseValues(d resourceGetter, from *valuesFrom) (map[string]interface{}, error) {
+	values, err := getValues(d)
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeMaps(from.values, values), nil
+}
+
+// getStateValuesFrom returns the values_from to cloak the values of the
+// release with when reading it. A ConfigMap or Secret that cannot be read
+// does not fail the refresh, which would prevent destroying the release, the
+// values cloaked in the state are cloaked again instead.
+func getStateValuesFrom(ctx context.Context, d *schema.ResourceData, m *Meta) (*valuesFrom, diag.Diagnostics) {
+	from, err := getValuesFrom(ctx, d, m)
+	if err == nil {
+		return from, nil
+	}
+
+	from = newValuesFrom()
+	from.unavailable = true
+
+	var previous interface{}
+	if err := json.Unmarshal([]byte(d.Get("metadata.0.values").(string)), &previous); err == nil {
+		from.secretPaths = cloakedPaths(previous, nil)
+	}
+
+	return from, diag.Diagnostics{{
+		Severity: diag.Warning,
+		Summary:  "Could not read values_from",
+		Detail:   fmt.Sprintf("%s. The values cloaked in the state are kept cloaked, and the manifest is not refreshed.", err),
+	}}
+}
+
+// leafPaths returns the paths of the values that are not maps
+func leafPaths(values map[string]interface{}, prefix []string) [][]string {
+	var paths [][]string
+
+	for k, v := range values {
+		path := append(append([]string{}, prefix...), k)
+		if vv, ok := v.(map[string]interface{}); ok {
+			paths = append(paths, leafPaths(vv, path)...)
+			continue
+		}
+		paths = append(paths, path)
+	}
+
+	return paths
+}
+
+// cloakedPaths returns the paths of the cloaked values
+func cloakedPaths(v interface{}, prefix []string) [][]string {
+	switch vv := v.(type) {
+	case string:
+		if vv == sensitiveContentValue {
+			return [][]string{prefix}
+		}
+	case map[string]interface{}:
+		var paths [][]string
+		for k, e := range vv {
+			paths = append(paths, cloakedPaths(e, append(append([]string{}, prefix...), k))...)
+		}
+		return paths
+	}
+	return nil
+}
+
+// cloakSecretValues replaces the values read from Secrets, by path, whatever
+// their type
+func cloakSecretValues(values map[string]interface{}, paths [][]string) {
+	for _, path := range paths {
+		if len(path) == 0 {
+			continue
+		}
+
+		m := values
+		for _, key := range path[:len(path)-1] {
+			v, ok := m[key].(map[string]interface{})
+			if !ok {
+				m = nil
+				break
+			}
+			m = v
+		}
+
+		if _, ok := m[path[len(path)-1]]; ok {
+			m[path[len(path)-1]] = sensitiveContentValue
+		}
+	}
+}
+
+// secretValue is a value read from a Secret, with its path in the values
+type secretValue struct {
+	path  []string
+	value interface{}
+}
+
+// leafValues returns the values that are not maps, with their paths
+func leafValues(values map[string]interface{}) []secretValue {
+	var result []secretValue
+	for _, path := range leafPaths(values, nil) {
+		v, _ := valueAtPath(values, path)
+		result = append(result, secretValue{path: path, value: v})
+	}
+	return result
+}
+
+// valueAtPath returns the value at the path of keys
+func valueAtPath(values map[string]interface{}, path []string) (interface{}, bool) {
+	var v interface{} = values
+	for _, key := range path {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// minSecretLength is the length of the shortest string redacted from the
+// manifest. Shorter strings are likely to be found in unrelated places.
+const minSecretLength = 6
+
+// secretStrings returns the strings of the secret values still set at their
+// path in the values, the ones overridden by other attributes not being in
+// the manifest. Short strings and the ones looking like booleans or numbers
+// are left out, as redacting them would rewrite unrelated parts of the
+// manifest.
+func secretStrings(secrets []secretValue, values map[string]interface{}) []string {
+	var result []string
+
+	for _, s := range secrets {
+		v, ok := valueAtPath(values, s.path)
+		if !ok || !reflect.DeepEqual(v, s.value) {
+			continue
+		}
+
+		for _, str := range jsonStrings(v) {
+			if isRedactable(str) {
+				result = append(result, str)
+			}
+		}
+	}
+
+	return result
+}
+
+func isRedactable(s string) bool {
+	if len(s) < minSecretLength {
+		return false
+	}
+	if _, err := strconv.ParseBool(s); err == nil {
+		return false
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return false
+	}
+	return true
+}
+
+// redactSecretValues removes the strings read from Secrets from the manifest
+// JSON, also when base64 encoded
+func redactSecretValues(text string, secrets []string) string {
+	masked := text
+
+	for _, s := range secrets {
+		masked = redactEncodedValue(masked, s)
+	}
+
+	return masked
+}
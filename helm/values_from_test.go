@@ -0,0 +1,134 @@
+package helm
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+func TestReadValuesFrom(t *testing.T) {
+	objects, err := parseLookupObjects(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-values
+  namespace: default
+data:
+  values.yaml: |
+    replicaCount: 2
+    image:
+      tag: "1.0"
+    db:
+      host: db.example.com
+      user: hunter2
+      password: placeholder
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: app-credentials
+  namespace: secrets
+data:
+  values.yaml: ZGI6CiAgcGFzc3dvcmQ6IGh1bnRlcjIKICBwaW46IDEyMzQKICB0bHM6ICJ0cnVlIgo=
+  token: czNjcjN0LHRva2Vu
+`, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := (&lookupFixtures{objects: objects}).ToRESTConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refs := []interface{}{
+		map[string]interface{}{"kind": "ConfigMap", "name": "app-values", "namespace": "", "key": "values.yaml", "target_path": ""},
+		map[string]interface{}{"kind": "Secret", "name": "app-credentials", "namespace": "secrets", "key": "values.yaml", "target_path": ""},
+		map[string]interface{}{"kind": "Secret", "name": "app-credentials", "namespace": "secrets", "key": "token", "target_path": "api.token"},
+	}
+
+	from, err := readValuesFrom(context.Background(), client, refs, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"replicaCount": float64(2),
+		"image":        map[string]interface{}{"tag": "1.0"},
+		"db":           map[string]interface{}{"host": "db.example.com", "user": "hunter2", "password": "hunter2", "pin": float64(1234), "tls": "true"},
+		"api":          map[string]interface{}{"token": "s3cr3t,token"},
+	}
+	if !reflect.DeepEqual(from.values, expected) {
+		t.Fatalf("expected values %v, got %v", expected, from.values)
+	}
+
+	for _, id := range []string{
+		"ConfigMap/default/app-values/values.yaml",
+		"Secret/secrets/app-credentials/values.yaml",
+		"Secret/secrets/app-credentials/token",
+	} {
+		if from.hashes[id] == nil {
+			t.Errorf("expected a hash for %s, got %v", id, from.hashes)
+		}
+	}
+
+	// only the strings of the secret still set in the values, and looking
+	// like secrets, are redacted
+	secrets := secretStrings(from.secrets, from.values)
+	sort.Strings(secrets)
+	if expected := []string{"hunter2", "s3cr3t,token"}; !reflect.DeepEqual(secrets, expected) {
+		t.Errorf("expected the strings %v to be redacted, got %v", expected, secrets)
+	}
+
+	masked := redactSecretValues(`{"data":{"token":"czNjcjN0LHRva2Vu"},"stringData":{"password":"hunter2","tls":"true"},"spec":{"enabled":"true"}}`, secrets)
+	if strings.Contains(masked, "czNjcjN0LHRva2Vu") || strings.Contains(masked, "hunter2") {
+		t.Errorf("expected the values of the secret to be redacted, got %s", masked)
+	}
+	if strings.Count(masked, `"true"`) != 2 {
+		t.Errorf("expected the short values to be left as they are, got %s", masked)
+	}
+
+	overridden := mergeMaps(from.values, map[string]interface{}{
+		"db": map[string]interface{}{"password": "overridden"},
+	})
+	if secrets := secretStrings(from.secrets, overridden); len(secrets) != 1 || secrets[0] != "s3cr3t,token" {
+		t.Errorf("expected the overridden value not to be redacted, got %v", secrets)
+	}
+
+	// the values of the secret are cloaked by path, whatever their type, and
+	// equal values read from elsewhere are left as they are
+	cloakSecretValues(from.values, from.secretPaths)
+	db := from.values["db"].(map[string]interface{})
+	if db["password"] != sensitiveContentValue || db["pin"] != sensitiveContentValue ||
+		from.values["api"].(map[string]interface{})["token"] != sensitiveContentValue ||
+		db["tls"] != sensitiveContentValue || db["host"] != "db.example.com" || db["user"] != "hunter2" {
+		t.Errorf("expected only the values of the secret to be cloaked, got %v", from.values)
+	}
+
+	// the paths cloaked in the state are found again
+	paths := cloakedPaths(map[string]interface{}{"values": from.values}, nil)
+	if len(paths) != 4 {
+		t.Errorf("expected 4 cloaked paths, got %v", paths)
+	}
+
+	refs = append(refs, map[string]interface{}{"kind": "ConfigMap", "name": "app-values", "namespace": "", "key": "missing", "target_path": ""})
+	if _, err := readValuesFrom(context.Background(), client, refs, "default"); err == nil {
+		t.Fatal("expected an error for a missing key")
+	}
+
+	refs = []interface{}{
+		map[string]interface{}{"kind": "Secret", "name": "missing", "namespace": "", "key": "values.yaml", "target_path": ""},
+	}
+	if _, err := readValuesFrom(context.Background(), client, refs, "default"); err == nil {
+		t.Fatal("expected an error for a missing secret")
+	}
+}
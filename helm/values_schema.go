@@ -0,0 +1,400 @@
+package helm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/xeipuuv/gojsonschema"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"sigs.k8s.io/yaml"
+)
+
+// unknownValue is the value Terraform gives to the attributes not known
+// until apply
+const unknownValue = "74D93920-ED26-11E3-AC10-0800200C9A66"
+
+// valuesSchemaViolation is a value not matching the values.schema.json of the
+// chart or of one of its subcharts
+type valuesSchemaViolation struct {
+	// name of the chart whose schema is not met
+	chart string
+	// path of the value, from the top of the release values
+	path string
+	// path of the value that has been checked. It is the object missing the
+	// value for required properties, and the value itself otherwise.
+	field string
+	// type of the error, like "required" or "invalid_type"
+	kind        string
+	description string
+
+	// where the value has been set, if found
+	source    string
+	attribute cty.Path
+}
+
+func (v valuesSchemaViolation) Error() string {
+	path := v.path
+	if path == "" {
+		path = "(root)"
+	}
+
+	msg := fmt.Sprintf("value %q does not match the schema of chart %s: %s", path, v.chart, v.description)
+	if v.source != "" {
+		// The entries of set blocks cannot be pointed at, they are named
+		// first instead
+		msg = fmt.Sprintf("%s: %s", v.source, msg)
+	}
+	return msg
+}
+
+// validateValuesSchema checks the values, coalesced with the ones of the
+// chart, against the schemas of the chart and of its enabled subcharts, as
+// Helm does when installing
+func validateValuesSchema(c *chart.Chart, values map[string]interface{}) ([]valuesSchemaViolation, error) {
+	if err := chartutil.ProcessDependencies(c, values); err != nil {
+		return nil, err
+	}
+
+	coalesced, err := chartutil.CoalesceValues(c, values)
+	if err != nil {
+		return nil, err
+	}
+
+	return chartSchemaViolations(c, coalesced, "")
+}
+
+func chartSchemaViolations(c *chart.Chart, values map[string]interface{}, prefix string) ([]valuesSchemaViolation, error) {
+	var violations []valuesSchemaViolation
+
+	if len(c.Schema) > 0 {
+		v, err := schemaViolations(c.Schema, values)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", c.Name(), err)
+		}
+
+		for _, e := range v {
+			e.chart = c.Name()
+			e.path = joinValuePath(prefix, e.path)
+			e.field = joinValuePath(prefix, e.field)
+			violations = append(violations, e)
+		}
+	}
+
+	for _, sub := range c.Dependencies() {
+		subValues, _ := values[sub.Name()].(map[string]interface{})
+		if subValues == nil {
+			subValues = map[string]interface{}{}
+		}
+
+		v, err := chartSchemaViolations(sub, subValues, joinValuePath(prefix, sub.Name()))
+		if err != nil {
+			return nil, err
+		}
+		violations = append(violations, v...)
+	}
+
+	return violations, nil
+}
+
+func schemaViolations(schemaJSON []byte, values map[string]interface{}) ([]valuesSchemaViolation, error) {
+	valuesJSON, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	if string(valuesJSON) == "null" {
+		valuesJSON = []byte("{}")
+	}
+
+	result, err := gojsonschema.Validate(
+		gojsonschema.NewBytesLoader(schemaJSON),
+		gojsonschema.NewBytesLoader(valuesJSON),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []valuesSchemaViolation
+
+	for _, e := range result.Errors() {
+		field := e.Field()
+		if field == gojsonschema.STRING_CONTEXT_ROOT {
+			field = ""
+		}
+
+		path := field
+		if property, ok := e.Details()["property"].(string); ok && e.Type() == "required" {
+			path = joinValuePath(field, property)
+		}
+
+		violations = append(violations, valuesSchemaViolation{
+			path:        path,
+			field:       field,
+			kind:        e.Type(),
+			description: e.Description(),
+		})
+	}
+
+	return violations, nil
+}
+
+func joinValuePath(prefix, path string) string {
+	if prefix == "" {
+		return path
+	}
+	if path == "" {
+		return prefix
+	}
+	return prefix + "." + path
+}
+
+var valuePathIndex = regexp.MustCompile(`\[(\d+)\]`)
+
+// normalizeValuePath turns a --set style name into the dotted form used in
+// the schema errors
+func normalizeValuePath(name string) string {
+	name = strings.ReplaceAll(name, `\.`, ".")
+	return valuePathIndex.ReplaceAllString(name, ".$1")
+}
+
+// overlapsValuePath tells if setting name sets, or is part of, the value at
+// field
+func overlapsValuePath(name, field string) bool {
+	name = normalizeValuePath(name)
+	return name == field ||
+		strings.HasPrefix(field, name+".") ||
+		strings.HasPrefix(name, field+".")
+}
+
+// hasValuePath tells if the parsed values hold a value at the dotted path
+func hasValuePath(values interface{}, path string) bool {
+	v := values
+	for _, key := range strings.Split(path, ".") {
+		switch vv := v.(type) {
+		case map[string]interface{}:
+			e, ok := vv[key]
+			if !ok {
+				return false
+			}
+			v = e
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(vv) {
+				return false
+			}
+			v = vv[i]
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// attributeValuesSchemaViolations finds where the values of the violations
+// have been set, looking at the sources from the highest precedence down.
+// Values coming from values_from or from the defaults of the chart are left
+// unattributed.
+func attributeValuesSchemaViolations(d resourceGetter, violations []valuesSchemaViolation) error {
+	type source struct {
+		label     string
+		attribute cty.Path
+		matches   func(field string) bool
+	}
+
+	var sources []source
+
+	setSource := func(key string, name string, attribute cty.Path) {
+		sources = append(sources, source{
+			label:     fmt.Sprintf("%s %q", key, name),
+			attribute: attribute,
+			matches:   func(field string) bool { return overlapsValuePath(name, field) },
+		})
+	}
+
+	for _, key := range []string{"set_from_file", "set_from_env", "set_sensitive", "set_file"} {
+		for _, raw := range d.Get(key).(*schema.Set).List() {
+			set := raw.(map[string]interface{})
+			setSource(key, set["name"].(string), cty.GetAttrPath(key))
+		}
+	}
+
+	setList := d.Get("set_list").([]interface{})
+	for i := len(setList) - 1; i >= 0; i-- {
+		set := setList[i].(map[string]interface{})
+		setSource("set_list", set["name"].(string), cty.GetAttrPath("set_list").IndexInt(i))
+	}
+
+	for _, raw := range d.Get("set").(*schema.Set).List() {
+		set := raw.(map[string]interface{})
+		setSource("set", set["name"].(string), cty.GetAttrPath("set"))
+	}
+
+	yamlSource := func(label string, attribute cty.Path, data []byte) {
+		var values interface{}
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return
+		}
+		sources = append(sources, source{
+			label:     label,
+			attribute: attribute,
+			matches:   func(field string) bool { return hasValuePath(values, field) },
+		})
+	}
+
+	values := d.Get("values").([]interface{})
+	for i := len(values) - 1; i >= 0; i-- {
+		v, _ := values[i].(string)
+		yamlSource(fmt.Sprintf("values[%d]", i), cty.GetAttrPath("values").IndexInt(i), []byte(v))
+	}
+
+	valuesFiles, err := getValuesFiles(d)
+	if err != nil {
+		return err
+	}
+	for i := len(valuesFiles) - 1; i >= 0; i-- {
+		data, err := ioutil.ReadFile(valuesFiles[i])
+		if err != nil {
+			return err
+		}
+		yamlSource(fmt.Sprintf("values_files %q", valuesFiles[i]), cty.GetAttrPath("values_files"), data)
+	}
+
+	for i, v := range violations {
+		if v.field == "" {
+			continue
+		}
+
+		for _, s := range sources {
+			if s.matches(v.field) {
+				violations[i].source = s.label
+				violations[i].attribute = s.attribute
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// redactValuesSchemaViolations removes the values of set_sensitive,
+// set_from_env and set_from_file, and the given secrets, from the
+// descriptions of the violations
+func redactValuesSchemaViolations(d resourceGetter, violations []valuesSchemaViolation, secrets []string) error {
+	sensitive := append([]string{}, secrets...)
+
+	for _, raw := range d.Get("set_sensitive").(*schema.Set).List() {
+		set := raw.(map[string]interface{})
+		value := set["value"].(string)
+
+		if set["type"] != "json" {
+			sensitive = append(sensitive, value)
+			continue
+		}
+
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(value), &decoded); err == nil {
+			sensitive = append(sensitive, jsonStrings(decoded)...)
+		}
+	}
+
+	setFromValues, err := getSetFromValues(d)
+	if err != nil {
+		return err
+	}
+	for _, v := range setFromValues {
+		sensitive = append(sensitive, v)
+	}
+
+	for i := range violations {
+		for _, s := range sensitive {
+			if s != "" {
+				violations[i].description = strings.ReplaceAll(violations[i].description, s, sensitiveContentValue)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkValuesSchema validates the values against the schemas of the chart,
+// returning the violations attributed to where their values have been set,
+// with the sensitive values redacted
+func checkValuesSchema(d resourceGetter, c *chart.Chart, values map[string]interface{}, secrets []string) ([]valuesSchemaViolation, error) {
+	violations, err := validateValuesSchema(c, values)
+	if err != nil || len(violations) == 0 {
+		return nil, err
+	}
+
+	if err := attributeValuesSchemaViolations(d, violations); err != nil {
+		return nil, err
+	}
+
+	if err := redactValuesSchemaViolations(d, violations, secrets); err != nil {
+		return nil, err
+	}
+
+	return violations, nil
+}
+
+// valuesSchemaError returns the first violation as an error, with the path
+// of the attribute its value has been set by, as CustomizeDiff can only
+// report one error. The number of other violations is given, they are each
+// reported by valuesSchemaDiagnostics otherwise.
+func valuesSchemaError(violations []valuesSchemaViolation) error {
+	v := violations[0]
+
+	var err error = v
+	if len(violations) > 1 {
+		err = fmt.Errorf("%s (%d more values do not match the chart schema)", v, len(violations)-1)
+	}
+
+	if v.attribute == nil {
+		return err
+	}
+	return v.attribute.NewError(err)
+}
+
+// valuesSchemaDiagnostics returns a diagnostic for each violation, pointing
+// at the attribute its value has been set by
+func valuesSchemaDiagnostics(violations []valuesSchemaViolation) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	for _, v := range violations {
+		diags = append(diags, diag.Diagnostic{
+			Severity:      diag.Error,
+			Summary:       "Values do not match the chart schema",
+			Detail:        v.Error(),
+			AttributePath: v.attribute,
+		})
+	}
+
+	return diags
+}
+
+// hasUnknownValue tells if any of the values is not known until apply
+func hasUnknownValue(v interface{}) bool {
+	switch vv := v.(type) {
+	case string:
+		return strings.Contains(vv, unknownValue)
+	case []interface{}:
+		for _, e := range vv {
+			if hasUnknownValue(e) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		for k, e := range vv {
+			if strings.Contains(k, unknownValue) || hasUnknownValue(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
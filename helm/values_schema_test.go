@@ -0,0 +1,195 @@
+package helm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+)
+
+func TestCheckValuesSchema(t *testing.T) {
+	c, err := loader.Load(filepath.Join(testChartsPath, "schema-chart"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := schema.TestResourceDataRaw(t, resourceRelease().Schema, map[string]interface{}{
+		"values": []interface{}{
+			"replicaCount: 2\n",
+			"replicaCount: -1\nimage:\n  tag: 1\n",
+		},
+		"set": []interface{}{
+			map[string]interface{}{"name": "service.port", "value": "70000", "type": ""},
+		},
+		"set_list": []interface{}{
+			map[string]interface{}{"name": "image.repository", "value": []interface{}{"nginx"}},
+		},
+	})
+
+	values, err := getValues(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	violations, err := checkValuesSchema(d, c, values, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]struct {
+		source    string
+		attribute cty.Path
+	}{
+		"replicaCount":     {`values[1]`, cty.GetAttrPath("values").IndexInt(1)},
+		"image.tag":        {`values[1]`, cty.GetAttrPath("values").IndexInt(1)},
+		"image.repository": {`set_list "image.repository"`, cty.GetAttrPath("set_list").IndexInt(0)},
+		"service.port":     {`set "service.port"`, cty.GetAttrPath("set")},
+	}
+
+	if len(violations) != len(expected) {
+		t.Fatalf("expected %d violations, got %v", len(expected), violations)
+	}
+
+	for _, v := range violations {
+		e, ok := expected[v.path]
+		if !ok {
+			t.Errorf("unexpected violation %s", v)
+			continue
+		}
+		if v.chart != "schema-chart" || v.source != e.source || !v.attribute.Equals(e.attribute) {
+			t.Errorf("expected %q to be from %s at %#v, got %s at %#v", v.path, e.source, e.attribute, v.source, v.attribute)
+		}
+	}
+
+	diags := valuesSchemaDiagnostics(violations)
+	if len(diags) != len(violations) {
+		t.Fatalf("expected a diagnostic per violation, got %v", diags)
+	}
+	for i, d := range diags {
+		if !d.AttributePath.Equals(violations[i].attribute) {
+			t.Errorf("expected the diagnostic of %s to point at its attribute, got %#v", violations[i], d.AttributePath)
+		}
+	}
+
+	if err := valuesSchemaError(violations); !strings.Contains(err.Error(), fmt.Sprintf("(%d more values", len(violations)-1)) {
+		t.Errorf("expected the error to give the number of other violations, got %s", err)
+	}
+
+	for _, v := range violations {
+		if v.path == "service.port" {
+			err := valuesSchemaError([]valuesSchemaViolation{v})
+			if _, ok := err.(cty.PathError); !ok {
+				t.Errorf("expected the error of a violation to have a path, got %T", err)
+			}
+			if !strings.HasPrefix(err.Error(), `set "service.port": `) {
+				t.Errorf("expected the error to name the set entry first, got %s", err)
+			}
+		}
+	}
+}
+
+func TestResourceReleaseValidateSchema(t *testing.T) {
+	c, err := loader.Load(filepath.Join(testChartsPath, "schema-chart"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := schema.TestResourceDataRaw(t, resourceRelease().Schema, map[string]interface{}{
+		"values": []interface{}{
+			"image:\n  repository: null\n",
+		},
+	})
+
+	// a value missing may be set by values_from, it is only reported once the
+	// values_from data is known
+	if err := resourceReleaseValidateSchema(d, c, nil); err != nil {
+		t.Errorf("expected no error without the values_from data, got %s", err)
+	}
+	if err := resourceReleaseValidateSchema(d, c, newValuesFrom()); err == nil || !strings.Contains(err.Error(), `"image.repository"`) {
+		t.Errorf("expected an error for image.repository, got %v", err)
+	}
+
+	// values set by the other attributes take precedence over values_from
+	d = schema.TestResourceDataRaw(t, resourceRelease().Schema, map[string]interface{}{
+		"values": []interface{}{
+			"replicaCount: -1\n",
+		},
+	})
+	if err := resourceReleaseValidateSchema(d, c, nil); err == nil || !strings.HasPrefix(err.Error(), "values[0]: ") {
+		t.Errorf("expected an error for values[0] without the values_from data, got %v", err)
+	}
+}
+
+func TestCheckValuesSchemaSubchart(t *testing.T) {
+	sub, err := loader.Load(filepath.Join(testChartsPath, "schema-chart"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: "v2", Name: "parent", Version: "0.1.0"},
+		Values:   map[string]interface{}{},
+	}
+	c.AddDependency(sub)
+
+	d := schema.TestResourceDataRaw(t, resourceRelease().Schema, map[string]interface{}{
+		"set": []interface{}{
+			map[string]interface{}{"name": "schema-chart.replicaCount", "value": "-1", "type": ""},
+		},
+	})
+
+	values, err := getValues(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	violations, err := checkValuesSchema(d, c, values, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(violations) != 1 {
+		t.Fatalf("expected a violation, got %v", violations)
+	}
+
+	v := violations[0]
+	if v.chart != "schema-chart" || v.path != "schema-chart.replicaCount" || v.source != `set "schema-chart.replicaCount"` {
+		t.Errorf("unexpected violation %s", v)
+	}
+}
+
+func TestRedactValuesSchemaViolations(t *testing.T) {
+	os.Setenv("TEST_SCHEMA_TOKEN", "t0ken")
+	defer os.Unsetenv("TEST_SCHEMA_TOKEN")
+
+	d := schema.TestResourceDataRaw(t, resourceRelease().Schema, map[string]interface{}{
+		"set_sensitive": []interface{}{
+			map[string]interface{}{"name": "password", "value": "hunter2", "type": ""},
+		},
+		"set_from_env": []interface{}{
+			map[string]interface{}{"name": "token", "env": "TEST_SCHEMA_TOKEN"},
+		},
+	})
+
+	violations := []valuesSchemaViolation{
+		{chart: "test", path: "password", description: "hunter2 is too short"},
+		{chart: "test", path: "token", description: "t0ken is too short"},
+		{chart: "test", path: "key", description: "s3cr3t is too short"},
+	}
+
+	if err := redactValuesSchemaViolations(d, violations, []string{"s3cr3t"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range violations {
+		if !strings.HasPrefix(v.description, sensitiveContentValue) {
+			t.Errorf("expected the value to be redacted, got %s", v)
+		}
+	}
+}